@@ -17,8 +17,10 @@ import (
 
 	_ "github.com/abdonasmane/etfs-simulator/backend/docs" // Swagger docs
 
+	"github.com/abdonasmane/etfs-simulator/backend/internal/cache"
 	"github.com/abdonasmane/etfs-simulator/backend/internal/config"
 	"github.com/abdonasmane/etfs-simulator/backend/internal/handler"
+	"github.com/abdonasmane/etfs-simulator/backend/internal/jobs"
 	"github.com/abdonasmane/etfs-simulator/backend/internal/marketdata"
 	"github.com/abdonasmane/etfs-simulator/backend/internal/metrics"
 	"github.com/abdonasmane/etfs-simulator/backend/internal/server"
@@ -65,8 +67,19 @@ func run() error {
 	// Initialize Prometheus metrics
 	m := metrics.New()
 
+	// Start the worker pool for asynchronous jobs
+	jobManager := jobs.New(jobs.Options{
+		Workers:   cfg.Jobs.Workers,
+		QueueSize: cfg.Jobs.QueueSize,
+		ResultTTL: cfg.Jobs.ResultTTL,
+	}, m)
+	defer jobManager.Close()
+
+	// Cache simulation responses for identical requests
+	results := cache.NewLRU[string, []byte](cfg.Cache.Size)
+
 	// Create HTTP handler
-	h := handler.New(indexService, m)
+	h := handler.New(indexService, jobManager, results, m)
 
 	// Create and start server
 	srv := server.New(server.Options{
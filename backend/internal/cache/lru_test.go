@@ -0,0 +1,37 @@
+package cache
+
+import "testing"
+
+// TestLRU tests that the least recently used entry is evicted when the cache is full.
+func TestLRU(t *testing.T) {
+	c := NewLRU[string, int](2)
+	c.Add("a", 1)
+	c.Add("b", 2)
+
+	// Reading "a" makes "b" the least recently used
+	if v, ok := c.Get("a"); !ok || v != 1 {
+		t.Fatalf("expected a=1, got %d (%v)", v, ok)
+	}
+	c.Add("c", 3)
+
+	if _, ok := c.Get("b"); ok {
+		t.Error("expected b to be evicted")
+	}
+	if v, ok := c.Get("c"); !ok || v != 3 {
+		t.Errorf("expected c=3, got %d (%v)", v, ok)
+	}
+
+	c.Add("a", 10)
+	if v, _ := c.Get("a"); v != 10 || c.Len() != 2 {
+		t.Errorf("expected a updated to 10 with 2 entries, got %d with %d entries", v, c.Len())
+	}
+}
+
+// TestLRUDisabled tests that a cache without capacity stores nothing.
+func TestLRUDisabled(t *testing.T) {
+	c := NewLRU[string, int](0)
+	c.Add("a", 1)
+	if _, ok := c.Get("a"); ok || c.Len() != 0 {
+		t.Error("expected a disabled cache to store nothing")
+	}
+}
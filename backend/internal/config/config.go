@@ -17,6 +17,12 @@ type Config struct {
 	// Server contains HTTP server configuration.
 	Server ServerConfig
 
+	// Jobs contains asynchronous job configuration.
+	Jobs JobsConfig
+
+	// Cache contains simulation result cache configuration.
+	Cache CacheConfig
+
 	// Env specifies the runtime environment (development, staging, production).
 	Env string
 }
@@ -42,6 +48,24 @@ type ServerConfig struct {
 	ShutdownTimeout time.Duration
 }
 
+// JobsConfig holds asynchronous job specific configuration.
+type JobsConfig struct {
+	// Workers is the number of jobs run concurrently.
+	Workers int
+
+	// QueueSize is the maximum number of jobs waiting for a worker.
+	QueueSize int
+
+	// ResultTTL is how long a finished job and its result are kept.
+	ResultTTL time.Duration
+}
+
+// CacheConfig holds simulation result cache specific configuration.
+type CacheConfig struct {
+	// Size is the maximum number of cached simulation responses (0 disables the cache).
+	Size int
+}
+
 // Addr returns the full address string in the format "host:port".
 func (s ServerConfig) Addr() string {
 	return fmt.Sprintf("%s:%d", s.Host, s.Port)
@@ -60,6 +84,14 @@ func Load() (*Config, error) {
 			IdleTimeout:     getEnvAsDuration("SERVER_IDLE_TIMEOUT", 120*time.Second),
 			ShutdownTimeout: getEnvAsDuration("SERVER_SHUTDOWN_TIMEOUT", 30*time.Second),
 		},
+		Jobs: JobsConfig{
+			Workers:   getEnvAsInt("JOBS_WORKERS", 4),
+			QueueSize: getEnvAsInt("JOBS_QUEUE_SIZE", 100),
+			ResultTTL: getEnvAsDuration("JOBS_RESULT_TTL", 15*time.Minute),
+		},
+		Cache: CacheConfig{
+			Size: getEnvAsInt("CACHE_SIZE", 128),
+		},
 	}
 
 	if err := cfg.validate(); errors.Check(err) {
@@ -75,6 +107,20 @@ func (c *Config) validate() error {
 		return errors.Errorf("server port must be between 1 and 65535, got %d", c.Server.Port)
 	}
 
+	if c.Jobs.Workers < 1 {
+		return errors.Errorf("jobs workers must be at least 1, got %d", c.Jobs.Workers)
+	}
+	if c.Jobs.QueueSize < 1 {
+		return errors.Errorf("jobs queue size must be at least 1, got %d", c.Jobs.QueueSize)
+	}
+	if c.Jobs.ResultTTL <= 0 {
+		return errors.Errorf("jobs result TTL must be positive, got %s", c.Jobs.ResultTTL)
+	}
+
+	if c.Cache.Size < 0 {
+		return errors.Errorf("cache size must not be negative, got %d", c.Cache.Size)
+	}
+
 	validEnvs := map[string]bool{
 		"development": true,
 		"staging":     true,
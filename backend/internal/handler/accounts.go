@@ -0,0 +1,226 @@
+package handler
+
+import (
+	"fmt"
+
+	"github.com/abdonasmane/etfs-simulator/backend/sdk/errors"
+)
+
+const (
+	// maxAccounts is the maximum number of accounts in a simulation.
+	maxAccounts = 10
+
+	// overflowAccountName names the uncapped account added when every account has a cap.
+	overflowAccountName = "taxable"
+)
+
+// Account is an investment account (e.g., ISA, PEA, IRA, 401k) with optional contribution limits.
+// Accounts are filled in the order they are listed.
+type Account struct {
+	Name string `json:"name" example:"ISA"`
+
+	// AnnualCap limits contributions per calendar year, initial investment included.
+	AnnualCap *float64 `json:"annualCap,omitempty" example:"20000"`
+
+	// LifetimeCap limits total contributions over the simulation.
+	LifetimeCap *float64 `json:"lifetimeCap,omitempty" example:"150000"`
+}
+
+// AccountValue is an account's value and contributions at the end of a month.
+type AccountValue struct {
+	Name        string  `json:"name" example:"ISA"`
+	Value       float64 `json:"value" example:"24890.10"`
+	Contributed float64 `json:"contributed" example:"22000"`
+}
+
+// AccountSummary is the final state of an account.
+type AccountSummary struct {
+	Name             string   `json:"name" example:"ISA"`
+	FinalValue       float64  `json:"finalValue" example:"61520.35"`
+	TotalContributed float64  `json:"totalContributed" example:"45000"`
+	AnnualCap        *float64 `json:"annualCap,omitempty" example:"20000"`
+	LifetimeCap      *float64 `json:"lifetimeCap,omitempty" example:"150000"`
+
+	// LifetimeCapReached is when the lifetime cap was exhausted (omitted if never).
+	LifetimeCapReached *MilestoneDate `json:"lifetimeCapReached,omitempty"`
+}
+
+// applyAccounts validates the accounts and stores them on the plan. An uncapped
+// overflow account is appended if every account has a cap, and echoed in the inputs.
+func applyAccounts(plan *simulationPlan, in *SimulationInputs) error {
+	if len(in.Accounts) == 0 {
+		return nil
+	}
+	if len(in.Accounts) > maxAccounts {
+		return errors.Errorf("at most %d accounts are allowed", maxAccounts)
+	}
+
+	names := make(map[string]bool, len(in.Accounts))
+	for _, a := range in.Accounts {
+		if a.Name == "" {
+			return errors.New("account name is required")
+		}
+		if names[a.Name] {
+			return errors.New("duplicate account name: " + a.Name)
+		}
+		names[a.Name] = true
+
+		if a.AnnualCap != nil && *a.AnnualCap <= 0 {
+			return errors.New("annualCap must be > 0 for account: " + a.Name)
+		}
+		if a.LifetimeCap != nil && *a.LifetimeCap <= 0 {
+			return errors.New("lifetimeCap must be > 0 for account: " + a.Name)
+		}
+	}
+
+	last := in.Accounts[len(in.Accounts)-1]
+	if last.AnnualCap != nil || last.LifetimeCap != nil {
+		name := overflowAccountName
+		for i := 2; names[name]; i++ {
+			name = fmt.Sprintf("%s-%d", overflowAccountName, i)
+		}
+		in.Accounts = append(in.Accounts, Account{Name: name})
+	}
+
+	plan.accounts = in.Accounts
+	return nil
+}
+
+// accountBook splits a run's contributions and value between accounts. Every account
+// holds the same investments, so each is a sleeve of the portfolio that receives its
+// routed contributions and the portfolio's growth on what it has invested.
+type accountBook struct {
+	accounts []Account
+
+	year        int       // calendar year of yearly
+	yearly      []float64 // contributions this calendar year
+	contributed []float64 // contributions over the run
+	invested    []float64 // value of invested money
+	pending     []float64 // contributions waiting for the next purchase
+
+	// exhausted marks the lifetime caps that have been reached.
+	exhausted []bool
+}
+
+// newAccountBook creates an empty book for the given accounts.
+func newAccountBook(accounts []Account) *accountBook {
+	n := len(accounts)
+	return &accountBook{
+		accounts:    accounts,
+		yearly:      make([]float64, n),
+		contributed: make([]float64, n),
+		invested:    make([]float64, n),
+		pending:     make([]float64, n),
+		exhausted:   make([]bool, n),
+	}
+}
+
+// contribute routes a contribution made in year through the accounts in order.
+func (b *accountBook) contribute(amount float64, year int) {
+	if year != b.year {
+		b.year = year
+		clear(b.yearly)
+	}
+
+	for i, a := range b.accounts {
+		if amount <= 0 {
+			return
+		}
+
+		room := amount
+		if a.AnnualCap != nil {
+			room = min(room, *a.AnnualCap-b.yearly[i])
+		}
+		if a.LifetimeCap != nil {
+			room = min(room, *a.LifetimeCap-b.contributed[i])
+		}
+		if room <= 0 {
+			continue
+		}
+
+		b.yearly[i] += room
+		b.contributed[i] += room
+		b.pending[i] += room
+		amount -= room
+
+		if a.LifetimeCap != nil && b.contributed[i] >= *a.LifetimeCap-1e-9 {
+			b.exhausted[i] = true
+		}
+	}
+}
+
+// purchase invests all pending contributions, sharing the fees paid in proportion.
+func (b *accountBook) purchase(amount, fees float64) {
+	if amount <= 0 {
+		return
+	}
+
+	net := 1 - fees/amount
+	for i, p := range b.pending {
+		b.invested[i] += p * net
+		b.pending[i] = 0
+	}
+}
+
+// grow applies one month of growth, as a ratio of the invested value, to every account.
+func (b *accountBook) grow(ratio float64) {
+	for i := range b.invested {
+		b.invested[i] *= 1 + ratio
+	}
+}
+
+// withdraw removes the same fraction of every account's invested and pending money.
+func (b *accountBook) withdraw(ratio float64) {
+	for i := range b.invested {
+		b.invested[i] *= 1 - ratio
+		b.pending[i] *= 1 - ratio
+	}
+}
+
+// record writes the account values into a projection.
+func (b *accountBook) record(p *MonthProjection) {
+	p.Accounts = make([]AccountValue, len(b.accounts))
+	for i, a := range b.accounts {
+		p.Accounts[i] = AccountValue{
+			Name:        a.Name,
+			Value:       round2(b.invested[i] + b.pending[i]),
+			Contributed: round2(b.contributed[i]),
+		}
+	}
+}
+
+// buildAccountSummaries summarizes each account from the projections.
+func buildAccountSummaries(plan *simulationPlan, projections []MonthProjection) []AccountSummary {
+	final := projections[len(projections)-1]
+
+	// The initial investment alone may exhaust a cap before the first month
+	initial := newAccountBook(plan.accounts)
+	initial.contribute(plan.initial, plan.startYear)
+
+	summaries := make([]AccountSummary, len(plan.accounts))
+	for i, a := range plan.accounts {
+		summaries[i] = AccountSummary{
+			Name:             a.Name,
+			FinalValue:       final.Accounts[i].Value,
+			TotalContributed: final.Accounts[i].Contributed,
+			AnnualCap:        a.AnnualCap,
+			LifetimeCap:      a.LifetimeCap,
+		}
+		if a.LifetimeCap == nil {
+			continue
+		}
+
+		if initial.exhausted[i] {
+			summaries[i].LifetimeCapReached = &MilestoneDate{Year: plan.startYear, Month: plan.startMonth, Age: plan.agePtr(plan.startYear, plan.startMonth)}
+			continue
+		}
+		for m, p := range projections {
+			if p.Accounts[i].Contributed >= round2(*a.LifetimeCap) {
+				summaries[i].LifetimeCapReached = &MilestoneDate{Year: p.Year, Month: p.Month, MonthsFromNow: m + 1, Age: p.Age}
+				break
+			}
+		}
+	}
+
+	return summaries
+}
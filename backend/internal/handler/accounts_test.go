@@ -0,0 +1,33 @@
+package handler
+
+import (
+	"math"
+	"testing"
+)
+
+// TestAccountBookOverflow tests that contributions fill accounts in order within their caps.
+func TestAccountBookOverflow(t *testing.T) {
+	annual, lifetime := 1000.0, 1500.0
+	book := newAccountBook([]Account{
+		{Name: "ISA", AnnualCap: &annual, LifetimeCap: &lifetime},
+		{Name: "taxable"},
+	})
+
+	book.contribute(800, 2025)
+	book.contribute(800, 2025) // 200 fits under the annual cap
+	book.contribute(800, 2026) // 500 left under the lifetime cap
+
+	if math.Abs(book.contributed[0]-1500) > 1e-9 || math.Abs(book.contributed[1]-900) > 1e-9 {
+		t.Errorf("expected 1500 and 900 contributed, got %.2f and %.2f", book.contributed[0], book.contributed[1])
+	}
+	if !book.exhausted[0] {
+		t.Errorf("expected the lifetime cap to be exhausted")
+	}
+
+	// Fees are shared in proportion, growth applies to every account
+	book.purchase(2400, 24)
+	book.grow(0.1)
+	if math.Abs(book.invested[0]-1500*0.99*1.1) > 1e-9 {
+		t.Errorf("expected ISA value %.2f, got %.2f", 1500*0.99*1.1, book.invested[0])
+	}
+}
@@ -0,0 +1,134 @@
+package handler
+
+import "github.com/abdonasmane/etfs-simulator/backend/sdk/errors"
+
+// RetirementSummary describes the month contributions stop at the retirement age.
+type RetirementSummary struct {
+	RetirementAge int `json:"retirementAge" example:"60"`
+	Year          int `json:"year" example:"2045"`
+	Month         int `json:"month" example:"3"`
+	MonthsFromNow int `json:"monthsFromNow" example:"233"`
+
+	// Portfolio values at retirement (range only present when IndexSymbol or Portfolio is provided)
+	PortfolioValue   float64  `json:"portfolioValue" example:"412500.80"`
+	PessimisticValue *float64 `json:"pessimisticValue,omitempty" example:"320110.00"`
+	OptimisticValue  *float64 `json:"optimisticValue,omitempty" example:"530420.00"`
+}
+
+// applyAge validates the birth date and retirement options and stores them on the plan.
+func applyAge(plan *simulationPlan, in *SimulationInputs) error {
+	if in.BirthYear == nil {
+		if in.RetirementAge != nil {
+			return errors.New("retirementAge requires birthYear")
+		}
+		return nil
+	}
+
+	birthYear, birthMonth, err := birthDate(in, plan.startYear)
+	if errors.Check(err) {
+		return err
+	}
+	plan.birthYear = birthYear
+	plan.birthMonth = birthMonth
+
+	if in.RetirementAge != nil {
+		if *in.RetirementAge <= plan.ageAt(plan.startYear, plan.startMonth) || *in.RetirementAge > 100 {
+			return errors.New("retirementAge must be greater than the current age and at most 100")
+		}
+		plan.retirementAge = in.RetirementAge
+	}
+
+	return nil
+}
+
+// birthDate validates the birth date of someone alive in startYear and writes the
+// default birth month back to in. in.BirthYear must be set.
+func birthDate(in *SimulationInputs, startYear int) (year, month int, err error) {
+	month = 1
+	if in.BirthMonth != nil {
+		month = *in.BirthMonth
+	}
+	in.BirthMonth = &month
+
+	if *in.BirthYear < 1900 || *in.BirthYear > startYear {
+		return 0, 0, errors.Errorf("birthYear must be between 1900 and %d", startYear)
+	}
+	if month < 1 || month > 12 {
+		return 0, 0, errors.New("birthMonth must be between 1 and 12")
+	}
+	return *in.BirthYear, month, nil
+}
+
+// targetAgeDate returns the month in which someone born in birthYear/birthMonth turns age.
+// The birth date is validated first, so its errors are not reported as target date errors.
+func targetAgeDate(in *SimulationInputs, age, startYear int) (year, month int, err error) {
+	if in.BirthYear == nil {
+		return 0, 0, errors.New("targetAge requires birthYear")
+	}
+
+	birthYear, birthMonth, err := birthDate(in, startYear)
+	if errors.Check(err) {
+		return 0, 0, err
+	}
+	return birthYear + age, birthMonth, nil
+}
+
+// ageAt returns the age in completed years at a given month. Birthdays count from the start of their month.
+func (p *simulationPlan) ageAt(year, month int) int {
+	age := year - p.birthYear
+	if month < p.birthMonth {
+		age--
+	}
+	return age
+}
+
+// agePtr returns the age at a given month, or nil when no birth date was provided.
+func (p *simulationPlan) agePtr(year, month int) *int {
+	if p.birthYear == 0 {
+		return nil
+	}
+	age := p.ageAt(year, month)
+	return &age
+}
+
+// retired reports whether contributions have stopped at a given month.
+func (p *simulationPlan) retired(year, month int) bool {
+	return p.retirementAge != nil && p.ageAt(year, month) >= *p.retirementAge
+}
+
+// retirementMonth returns the index of the first month without contributions, or
+// totalMonths if the retirement age is not reached within the simulation.
+func (p *simulationPlan) retirementMonth() int {
+	if p.retirementAge == nil {
+		return p.totalMonths
+	}
+
+	year, month := p.startYear, p.startMonth
+	for i := range p.totalMonths {
+		year, month = nextMonth(year, month)
+		if p.retired(year, month) {
+			return i
+		}
+	}
+	return p.totalMonths
+}
+
+// buildRetirementSummary returns the first month without contributions, or nil if
+// the retirement age is not reached within the simulation.
+func buildRetirementSummary(plan *simulationPlan, projections []MonthProjection) *RetirementSummary {
+	for i, p := range projections {
+		if !plan.retired(p.Year, p.Month) {
+			continue
+		}
+		return &RetirementSummary{
+			RetirementAge:    *plan.retirementAge,
+			Year:             p.Year,
+			Month:            p.Month,
+			MonthsFromNow:    i + 1,
+			PortfolioValue:   p.PortfolioValue,
+			PessimisticValue: p.PessimisticValue,
+			OptimisticValue:  p.OptimisticValue,
+		}
+	}
+	return nil
+}
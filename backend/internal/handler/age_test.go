@@ -0,0 +1,96 @@
+package handler
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/abdonasmane/etfs-simulator/backend/sdk/errors"
+)
+
+// TestAgeAt tests that birthdays count from the start of the birth month.
+func TestAgeAt(t *testing.T) {
+	plan := &simulationPlan{birthYear: 1985, birthMonth: 4}
+
+	tests := []struct {
+		year, month, want int
+	}{
+		{year: 2025, month: 3, want: 39},
+		{year: 2025, month: 4, want: 40},
+		{year: 2025, month: 12, want: 40},
+	}
+	for _, tt := range tests {
+		if got := plan.ageAt(tt.year, tt.month); got != tt.want {
+			t.Errorf("ageAt(%d, %d) = %d, want %d", tt.year, tt.month, got, tt.want)
+		}
+	}
+}
+
+// TestContributionsStopAtRetirement tests that no contributions are made from the retirement month.
+func TestContributionsStopAtRetirement(t *testing.T) {
+	retirementAge := 60
+	plan := &simulationPlan{
+		monthlyBase:   100,
+		startYear:     2025,
+		startMonth:    1,
+		totalMonths:   12,
+		birthYear:     1965,
+		birthMonth:    6,
+		retirementAge: &retirementAge,
+	}
+
+	for i, c := range plan.contributionSchedule() {
+		// Month i is February 2025 + i; June 2025 (i = 4) is the 60th birthday
+		want := 100.0
+		if i >= 4 {
+			want = 0
+		}
+		if c.employee != want {
+			t.Errorf("month %d: expected contribution %.0f, got %.0f", i, want, c.employee)
+		}
+	}
+}
+
+// TestTargetPlanAge tests that a target age resolves to its birthday month, and that an
+// invalid birth date is reported as such rather than as an invalid target date.
+func TestTargetPlanAge(t *testing.T) {
+	h := newTestHandler()
+	now := time.Date(2025, 6, 15, 0, 0, 0, 0, time.UTC)
+	intPtr := func(v int) *int { return &v }
+
+	tests := []struct {
+		name                  string
+		birthYear, birthMonth *int
+		wantYear, wantMonth   int
+		wantErr               string
+	}{
+		{name: "birth month", birthYear: intPtr(1985), birthMonth: intPtr(4), wantYear: 2045, wantMonth: 4},
+		{name: "default birth month", birthYear: intPtr(1985), wantYear: 2045, wantMonth: 1},
+		{name: "no birth year", wantErr: "targetAge requires birthYear"},
+		{name: "invalid birth month", birthYear: intPtr(1985), birthMonth: intPtr(13), wantErr: "birthMonth"},
+		{name: "invalid birth year", birthYear: intPtr(1800), birthMonth: intPtr(4), wantErr: "birthYear"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := SimulateByTargetRequest{
+				SimulationInputs: SimulationInputs{BirthYear: tt.birthYear, BirthMonth: tt.birthMonth},
+				TargetAge:        intPtr(60),
+			}
+			_, err := h.targetPlan(&req, now)
+
+			if tt.wantErr != "" {
+				if !errors.Check(err) || !strings.HasPrefix(err.Error(), tt.wantErr) {
+					t.Errorf("expected an error starting with %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if errors.Check(err) {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if req.TargetYear != tt.wantYear || *req.TargetMonth != tt.wantMonth {
+				t.Errorf("expected a target date of %d-%02d, got %d-%02d", tt.wantYear, tt.wantMonth, req.TargetYear, *req.TargetMonth)
+			}
+		})
+	}
+}
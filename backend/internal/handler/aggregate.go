@@ -0,0 +1,279 @@
+package handler
+
+import (
+	"fmt"
+
+	"github.com/abdonasmane/etfs-simulator/backend/sdk/errors"
+)
+
+// Projection granularities.
+const (
+	granularityMonthly   = "monthly"
+	granularityQuarterly = "quarterly"
+	granularityYearly    = "yearly"
+)
+
+// periodFields lists the selectable period values.
+var periodFields = []string{
+	"contributions",
+	"growth",
+	"endValue",
+	"totalContributed",
+	"monthlyContribution",
+	"pessimisticValue",
+	"optimisticValue",
+	"holdings",
+	"shares",
+	"cash",
+	"feesPaid",
+	"costBasis",
+	"unrealizedGain",
+	"liquidationTax",
+	"accounts",
+	"withdrawals",
+	"externalIncome",
+	"benchmarks",
+}
+
+// PeriodProjection aggregates monthly projections over a calendar month, quarter or year.
+// The first and last periods may be partial. Value fields are omitted when not selected.
+type PeriodProjection struct {
+	// Period labels the period: "2030", "2030-Q2" or "2030-06".
+	Period string `json:"period" example:"2030"`
+	Year   int    `json:"year" example:"2030"`
+
+	// Age at the end of the period (only present when BirthYear is provided)
+	Age *int `json:"age,omitempty" example:"45"`
+
+	// Months is the number of simulated months in the period.
+	Months int `json:"months" example:"12"`
+
+	// Contributions is the amount contributed during the period.
+	Contributions *float64 `json:"contributions,omitempty" example:"6000"`
+
+	// Growth is the investment growth during the period (change in value minus contributions plus withdrawals).
+	Growth *float64 `json:"growth,omitempty" example:"4120.55"`
+
+	// EndValue is the portfolio value at the end of the period.
+	EndValue *float64 `json:"endValue,omitempty" example:"62450.10"`
+
+	TotalContributed    *float64 `json:"totalContributed,omitempty" example:"46000"`
+	MonthlyContribution *float64 `json:"monthlyContribution,omitempty" example:"515.00"`
+
+	// Range values at the end of the period (only present when IndexSymbol or Portfolio is provided)
+	PessimisticValue *float64 `json:"pessimisticValue,omitempty" example:"58000.00"`
+	OptimisticValue  *float64 `json:"optimisticValue,omitempty" example:"67000.00"`
+
+	// Holdings at the end of the period (only present when Portfolio is provided)
+	Holdings []SymbolValue `json:"holdings,omitempty"`
+
+	// Share positions and uninvested cash at the end of the period (only present when ShareMode
+	// or InvestEveryMonths is provided)
+	Shares []ShareHolding `json:"shares,omitempty"`
+	Cash   *float64       `json:"cash,omitempty"`
+
+	// FeesPaid is the cumulative commission paid by the end of the period (only present when Commission is provided)
+	FeesPaid *float64 `json:"feesPaid,omitempty"`
+
+	// Cost basis and unrealized gain at the end of the period (only present when CostBasisMethod
+	// or CapitalGainsTaxRate is provided), and the tax owed if liquidated then (only present
+	// when CapitalGainsTaxRate is provided)
+	CostBasis      *float64 `json:"costBasis,omitempty"`
+	UnrealizedGain *float64 `json:"unrealizedGain,omitempty"`
+	LiquidationTax *float64 `json:"liquidationTax,omitempty"`
+
+	// Accounts at the end of the period (only present when Accounts is provided)
+	Accounts []AccountValue `json:"accounts,omitempty"`
+
+	// Amounts withdrawn from the portfolio and received from income streams during the period
+	// (only present when Decumulation is provided)
+	Withdrawals    *float64 `json:"withdrawals,omitempty"`
+	ExternalIncome *float64 `json:"externalIncome,omitempty"`
+
+	// Baseline values at the end of the period (only present when Benchmarks is provided)
+	Benchmarks *BenchmarkValues `json:"benchmarks,omitempty"`
+}
+
+// applyGranularity validates the projection shaping options and stores them on the plan.
+func applyGranularity(plan *simulationPlan, granularity *string, fields []string) error {
+	plan.granularity = granularityMonthly
+	if granularity != nil && *granularity != "" {
+		plan.granularity = *granularity
+	}
+
+	switch plan.granularity {
+	case granularityMonthly, granularityQuarterly, granularityYearly:
+	default:
+		return errors.New("granularity must be \"monthly\", \"quarterly\" or \"yearly\"")
+	}
+
+	if len(fields) > 0 {
+		valid := make(map[string]bool, len(periodFields))
+		for _, f := range periodFields {
+			valid[f] = true
+		}
+
+		plan.fields = make(map[string]bool, len(fields))
+		for _, f := range fields {
+			if !valid[f] {
+				return errors.New("unknown projection field: " + f)
+			}
+			plan.fields[f] = true
+		}
+	}
+
+	plan.aggregate = plan.granularity != granularityMonthly || plan.fields != nil
+	return nil
+}
+
+// aggregateProjections groups monthly projections into calendar periods.
+// initial is the value before the first projection, used for the first period's growth.
+func aggregateProjections(projections []MonthProjection, initial float64, granularity string) []PeriodProjection {
+	periods := []PeriodProjection{}
+	startValue := initial
+	startContributed := initial
+	var startWithdrawn float64
+
+	for i := 0; i < len(projections); {
+		label := periodLabel(projections[i], granularity)
+
+		// Find the last month of this period
+		j := i
+		for j+1 < len(projections) && periodLabel(projections[j+1], granularity) == label {
+			j++
+		}
+
+		end := projections[j]
+		contributions := round2(end.TotalContributed - startContributed)
+
+		var withdrawals, externalIncome *float64
+		endWithdrawn := startWithdrawn
+		if end.TotalWithdrawn != nil {
+			endWithdrawn = *end.TotalWithdrawn
+			withdrawn := round2(endWithdrawn - startWithdrawn)
+			var income float64
+			for _, p := range projections[i : j+1] {
+				if p.ExternalIncome != nil {
+					income += *p.ExternalIncome
+				}
+			}
+			income = round2(income)
+			withdrawals, externalIncome = &withdrawn, &income
+		}
+		growth := round2(end.PortfolioValue - startValue - contributions + endWithdrawn - startWithdrawn)
+		endValue := end.PortfolioValue
+		totalContributed := end.TotalContributed
+		monthlyContribution := end.MonthlyContribution
+
+		periods = append(periods, PeriodProjection{
+			Period:              label,
+			Year:                end.Year,
+			Age:                 end.Age,
+			Months:              j - i + 1,
+			Contributions:       &contributions,
+			Growth:              &growth,
+			EndValue:            &endValue,
+			TotalContributed:    &totalContributed,
+			MonthlyContribution: &monthlyContribution,
+			PessimisticValue:    end.PessimisticValue,
+			OptimisticValue:     end.OptimisticValue,
+			Holdings:            end.Holdings,
+			Shares:              end.Shares,
+			Cash:                end.Cash,
+			FeesPaid:            end.FeesPaid,
+			CostBasis:           end.CostBasis,
+			UnrealizedGain:      end.UnrealizedGain,
+			LiquidationTax:      end.LiquidationTax,
+			Accounts:            end.Accounts,
+			Withdrawals:         withdrawals,
+			ExternalIncome:      externalIncome,
+			Benchmarks:          end.Benchmarks,
+		})
+
+		startValue = end.PortfolioValue
+		startContributed = end.TotalContributed
+		startWithdrawn = endWithdrawn
+		i = j + 1
+	}
+
+	return periods
+}
+
+// periodLabel returns the label of the period containing a projection.
+func periodLabel(p MonthProjection, granularity string) string {
+	switch granularity {
+	case granularityYearly:
+		return fmt.Sprintf("%d", p.Year)
+	case granularityQuarterly:
+		return fmt.Sprintf("%d-Q%d", p.Year, (p.Month-1)/3+1)
+	default:
+		return fmt.Sprintf("%d-%02d", p.Year, p.Month)
+	}
+}
+
+// selectFields clears the period values that were not selected. A nil selection keeps all fields.
+func selectFields(periods []PeriodProjection, fields map[string]bool) []PeriodProjection {
+	if fields == nil {
+		return periods
+	}
+
+	for i := range periods {
+		p := &periods[i]
+		if !fields["contributions"] {
+			p.Contributions = nil
+		}
+		if !fields["growth"] {
+			p.Growth = nil
+		}
+		if !fields["endValue"] {
+			p.EndValue = nil
+		}
+		if !fields["totalContributed"] {
+			p.TotalContributed = nil
+		}
+		if !fields["monthlyContribution"] {
+			p.MonthlyContribution = nil
+		}
+		if !fields["pessimisticValue"] {
+			p.PessimisticValue = nil
+		}
+		if !fields["optimisticValue"] {
+			p.OptimisticValue = nil
+		}
+		if !fields["holdings"] {
+			p.Holdings = nil
+		}
+		if !fields["shares"] {
+			p.Shares = nil
+		}
+		if !fields["cash"] {
+			p.Cash = nil
+		}
+		if !fields["feesPaid"] {
+			p.FeesPaid = nil
+		}
+		if !fields["costBasis"] {
+			p.CostBasis = nil
+		}
+		if !fields["unrealizedGain"] {
+			p.UnrealizedGain = nil
+		}
+		if !fields["liquidationTax"] {
+			p.LiquidationTax = nil
+		}
+		if !fields["accounts"] {
+			p.Accounts = nil
+		}
+		if !fields["withdrawals"] {
+			p.Withdrawals = nil
+		}
+		if !fields["externalIncome"] {
+			p.ExternalIncome = nil
+		}
+		if !fields["benchmarks"] {
+			p.Benchmarks = nil
+		}
+	}
+
+	return periods
+}
@@ -0,0 +1,57 @@
+package handler
+
+import "testing"
+
+// TestAggregateProjectionsYearly tests yearly aggregation with partial first and last years.
+func TestAggregateProjectionsYearly(t *testing.T) {
+	plan := &simulationPlan{
+		initial:     1000,
+		monthlyBase: 100,
+		startYear:   2025,
+		startMonth:  10,
+		totalMonths: 15,
+	}
+	projections := simulateMonthly(plan, 6)
+
+	periods := aggregateProjections(projections, plan.initial, granularityYearly)
+	if len(periods) != 3 {
+		t.Fatalf("expected 3 periods, got %d", len(periods))
+	}
+
+	wantMonths := []int{2, 12, 1}
+	for i, p := range periods {
+		if p.Months != wantMonths[i] {
+			t.Errorf("period %s: expected %d months, got %d", p.Period, wantMonths[i], p.Months)
+		}
+	}
+
+	if *periods[1].Contributions != 1200 {
+		t.Errorf("expected 1200 contributed in 2026, got %.2f", *periods[1].Contributions)
+	}
+
+	// Contributions and growth across all periods must add up to the final value
+	total := plan.initial
+	for _, p := range periods {
+		total += *p.Contributions + *p.Growth
+	}
+	final := projections[len(projections)-1].PortfolioValue
+	if diff := total - final; diff > 0.05 || diff < -0.05 {
+		t.Errorf("periods sum to %.2f, final value is %.2f", total, final)
+	}
+}
+
+// TestSelectFields tests that unselected period values are cleared.
+func TestSelectFields(t *testing.T) {
+	plan := &simulationPlan{initial: 1000, monthlyBase: 100, startYear: 2025, startMonth: 1, totalMonths: 6}
+	periods := aggregateProjections(simulateMonthly(plan, 6), plan.initial, granularityQuarterly)
+
+	periods = selectFields(periods, map[string]bool{"endValue": true})
+	for _, p := range periods {
+		if p.EndValue == nil {
+			t.Errorf("period %s: expected endValue", p.Period)
+		}
+		if p.Contributions != nil || p.Growth != nil || p.TotalContributed != nil {
+			t.Errorf("period %s: expected unselected fields to be cleared", p.Period)
+		}
+	}
+}
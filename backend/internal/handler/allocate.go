@@ -0,0 +1,221 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/abdonasmane/etfs-simulator/backend/internal/portfolio"
+	"github.com/abdonasmane/etfs-simulator/backend/sdk/errors"
+)
+
+// Allocation methods.
+const (
+	allocationMethodEqualWeight       = "equalWeight"
+	allocationMethodInverseVolatility = "inverseVolatility"
+	allocationMethodRiskParity        = "riskParity"
+	allocationMethodMinDrawdown       = "minDrawdown"
+)
+
+// allocationMethods lists every allocation method, in the default order.
+var allocationMethods = []string{
+	allocationMethodEqualWeight,
+	allocationMethodInverseVolatility,
+	allocationMethodRiskParity,
+	allocationMethodMinDrawdown,
+}
+
+// --- Request Types ---
+
+// AllocateRequest is the input for rule-based allocation builders.
+type AllocateRequest struct {
+	// Symbols are the ETFs to allocate between (2-10). "CASH" adds a cash sleeve earning
+	// CashInterestRate; it has no volatility, so it only works with "equalWeight" and "minDrawdown".
+	Symbols []string `json:"symbols" example:"SPY,QQQ,EFA,AGG"`
+
+	// CashInterestRate is the annual interest rate of the "CASH" symbol.
+	CashInterestRate *float64 `json:"cashInterestRate,omitempty" example:"3"`
+
+	// Methods are the builders to run: "equalWeight", "inverseVolatility", "riskParity"
+	// (equal risk contribution) and "minDrawdown" (default: all of them that work with Symbols).
+	Methods []string `json:"methods,omitempty" example:"riskParity,minDrawdown"`
+}
+
+// --- Response Types ---
+
+// AllocateResponse is the output for allocation builders.
+type AllocateResponse struct {
+	Inputs AllocateRequest `json:"inputs"`
+
+	// HistoryMonths is the number of months of shared history the allocations are based on.
+	HistoryMonths int `json:"historyMonths" example:"240"`
+
+	// Allocations holds one allocation per method, in method order.
+	Allocations []BuiltAllocation `json:"allocations"`
+}
+
+// BuiltAllocation is the portfolio a method builds with its historical annual return and risk.
+type BuiltAllocation struct {
+	Method string `json:"method" example:"riskParity"`
+
+	// Portfolio can be sent as is as the Portfolio of a simulation request (zero weights are left out).
+	Portfolio []PortfolioAllocation `json:"portfolio"`
+
+	ExpectedReturn float64 `json:"expectedReturn" example:"7.8"`
+	Volatility     float64 `json:"volatility" example:"8.1"`
+
+	// MaxDrawdown is the largest historical peak-to-trough loss of the monthly-rebalanced portfolio.
+	MaxDrawdown float64 `json:"maxDrawdown" example:"32.5"`
+
+	// RiskContributions is each symbol's share of the portfolio variance, in symbol order.
+	RiskContributions []RiskContribution `json:"riskContributions"`
+}
+
+// RiskContribution is a symbol's weight and share of the portfolio variance, in percent.
+type RiskContribution struct {
+	Symbol           string  `json:"symbol" example:"SPY"`
+	Weight           float64 `json:"weight" example:"22.4"`
+	RiskContribution float64 `json:"riskContribution" example:"25"`
+}
+
+// --- Handler ---
+
+// handleAllocatePortfolio builds portfolio weights from historical data with rule-based methods.
+//
+//	@Summary		Build allocations
+//	@Description	Derives portfolio weights from the aligned monthly history of the symbols with equal weight, inverse volatility, risk parity and minimum drawdown builders, with their historical risk contributions
+//	@Tags			portfolio
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		AllocateRequest	true	"Symbols and methods"
+//	@Param			If-None-Match	header		string	false	"ETag of a previous identical request"
+//	@Success		200		{object}	AllocateResponse
+//	@Header			200		{string}	ETag	"Validator for If-None-Match"
+//	@Success		304		"Not Modified"
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		500		{object}	ErrorResponse
+//	@Router			/api/v1/portfolio/allocate [post]
+func (h *Handler) handleAllocatePortfolio(w http.ResponseWriter, r *http.Request) {
+	var req AllocateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); errors.Check(err) {
+		respondError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	respondCached(h, w, r, cacheEndpointAllocate, &req, h.prepareAllocate, func(resp *AllocateResponse) {
+		slog.Debug("allocation builders completed",
+			slog.Int("symbols", len(resp.Inputs.Symbols)),
+			slog.Int("methods", len(resp.Allocations)),
+			slog.Int("history_months", resp.HistoryMonths),
+		)
+	})
+}
+
+// --- Allocation ---
+
+// prepareAllocate validates an allocation request and loads its history. Its run applies
+// each requested builder.
+func (h *Handler) prepareAllocate(req *AllocateRequest, _ time.Time) (func(context.Context) (*AllocateResponse, error), error) {
+	// The cash sleeve has no volatility to weight by
+	withCash := slices.Contains(req.Symbols, cashSymbol)
+	volatilityBased := func(method string) bool {
+		return method == allocationMethodInverseVolatility || method == allocationMethodRiskParity
+	}
+
+	if len(req.Methods) == 0 {
+		for _, method := range allocationMethods {
+			if !withCash || !volatilityBased(method) {
+				req.Methods = append(req.Methods, method)
+			}
+		}
+	}
+
+	seen := make(map[string]bool, len(req.Methods))
+	for _, method := range req.Methods {
+		if !slices.Contains(allocationMethods, method) {
+			return nil, errors.New("method must be \"equalWeight\", \"inverseVolatility\", \"riskParity\" or \"minDrawdown\"")
+		}
+		if seen[method] {
+			return nil, errors.New("duplicate method: " + method)
+		}
+		seen[method] = true
+
+		if withCash && volatilityBased(method) {
+			return nil, errors.New(cashSymbol + " has no volatility, so it cannot be used with method: " + method)
+		}
+	}
+
+	returns, err := h.assetHistory(req.Symbols, req.CashInterestRate)
+	if errors.Check(err) {
+		return nil, err
+	}
+
+	return func(context.Context) (*AllocateResponse, error) {
+		e := portfolio.Estimate(returns)
+		resp := &AllocateResponse{
+			Inputs:        *req,
+			HistoryMonths: len(returns),
+			Allocations:   make([]BuiltAllocation, 0, len(req.Methods)),
+		}
+
+		for _, method := range req.Methods {
+			weights, err := buildAllocation(method, e, returns)
+			if errors.Check(err) {
+				return nil, err
+			}
+			resp.Allocations = append(resp.Allocations, builtAllocation(method, e, returns, req.Symbols, weights))
+		}
+
+		return resp, nil
+	}, nil
+}
+
+// buildAllocation returns the weights an allocation method derives from the history.
+func buildAllocation(method string, e portfolio.Estimates, returns [][]float64) ([]float64, error) {
+	switch method {
+	case allocationMethodEqualWeight:
+		return portfolio.EqualWeight(len(e.Mean)), nil
+	case allocationMethodInverseVolatility:
+		return portfolio.InverseVolatility(e)
+	case allocationMethodRiskParity:
+		return portfolio.EqualRiskContribution(e)
+	case allocationMethodMinDrawdown:
+		return portfolio.MinDrawdown(returns), nil
+	default:
+		return nil, errors.New("method must be \"equalWeight\", \"inverseVolatility\", \"riskParity\" or \"minDrawdown\"")
+	}
+}
+
+// builtAllocation reports a method's weights as a simulation portfolio with their historical
+// return, risk and risk contributions. Risk contributions report the portfolio's rounded
+// weights, so both always agree.
+func builtAllocation(method string, e portfolio.Estimates, returns [][]float64, symbols []string, weights []float64) BuiltAllocation {
+	contributions := portfolio.RiskContributions(e, weights)
+	allocations := toAllocations(symbols, weights)
+
+	allocation := BuiltAllocation{
+		Method:            method,
+		Portfolio:         allocations,
+		ExpectedReturn:    round2(e.Return(weights) * 100),
+		Volatility:        round2(e.Volatility(weights) * 100),
+		MaxDrawdown:       round2(portfolio.MaxDrawdown(returns, weights) * 100),
+		RiskContributions: make([]RiskContribution, len(symbols)),
+	}
+	for i, symbol := range symbols {
+		// Symbols left out of the portfolio have a zero weight
+		var weight float64
+		if j := slices.IndexFunc(allocations, func(a PortfolioAllocation) bool { return a.Symbol == symbol }); j >= 0 {
+			weight = allocations[j].Weight
+		}
+		allocation.RiskContributions[i] = RiskContribution{
+			Symbol:           symbol,
+			Weight:           weight,
+			RiskContribution: round2(contributions[i] * 100),
+		}
+	}
+
+	return allocation
+}
@@ -0,0 +1,89 @@
+package handler
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/abdonasmane/etfs-simulator/backend/internal/portfolio"
+	"github.com/abdonasmane/etfs-simulator/backend/sdk/errors"
+)
+
+// TestBuiltAllocation tests that every method builds weights whose risk contributions sum to 100.
+func TestBuiltAllocation(t *testing.T) {
+	returns := [][]float64{{0.02, 0.01}, {-0.04, 0.00}, {0.03, 0.02}, {0.01, -0.01}}
+	e := portfolio.Estimate(returns)
+
+	for _, method := range allocationMethods {
+		weights, err := buildAllocation(method, e, returns)
+		if errors.Check(err) {
+			t.Fatalf("%s: unexpected error: %v", method, err)
+		}
+
+		allocation := builtAllocation(method, e, returns, []string{"SPY", "AGG"}, weights)
+		var total float64
+		for _, rc := range allocation.RiskContributions {
+			total += rc.RiskContribution
+		}
+		if round1(total) != 100 {
+			t.Errorf("%s: expected risk contributions to sum to 100, got %v", method, total)
+		}
+		for _, a := range allocation.Portfolio {
+			i := slices.Index([]string{"SPY", "AGG"}, a.Symbol)
+			if allocation.RiskContributions[i].Weight != a.Weight {
+				t.Errorf("%s: expected the %s risk contribution weight to match its portfolio weight %v, got %v", method, a.Symbol, a.Weight, allocation.RiskContributions[i].Weight)
+			}
+		}
+	}
+
+	if _, err := buildAllocation("magic", e, returns); !errors.Check(err) {
+		t.Error("expected an error for an unknown method")
+	}
+}
+
+// TestPrepareAllocate tests that unknown and duplicate methods, and volatility-based methods
+// with a cash sleeve, are rejected before running, and that cash defaults to the methods it works with.
+func TestPrepareAllocate(t *testing.T) {
+	h := newTestHandler()
+	cashRate := 3.0
+
+	tests := []struct {
+		name    string
+		symbols []string
+		methods []string
+		wantErr string
+	}{
+		{name: "unknown method", symbols: []string{"SPY", "QQQ"}, methods: []string{"magic"}, wantErr: "method must be"},
+		{name: "duplicate method", symbols: []string{"SPY", "QQQ"}, methods: []string{"riskParity", "riskParity"}, wantErr: "duplicate method"},
+		{name: "cash with inverse volatility", symbols: []string{"SPY", "CASH"}, methods: []string{"inverseVolatility"}, wantErr: "CASH has no volatility"},
+		{name: "cash with risk parity", symbols: []string{"SPY", "CASH"}, methods: []string{"equalWeight", "riskParity"}, wantErr: "CASH has no volatility"},
+		{name: "cash with default methods", symbols: []string{"SPY", "CASH"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := AllocateRequest{Symbols: tt.symbols, CashInterestRate: &cashRate, Methods: tt.methods}
+			run, err := h.prepareAllocate(&req, time.Now())
+
+			if tt.wantErr != "" {
+				if !errors.Check(err) || !strings.HasPrefix(err.Error(), tt.wantErr) {
+					t.Errorf("expected an error starting with %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if errors.Check(err) {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if want := []string{allocationMethodEqualWeight, allocationMethodMinDrawdown}; !slices.Equal(req.Methods, want) {
+				t.Errorf("expected default methods %v, got %v", want, req.Methods)
+			}
+			resp, err := run(context.Background())
+			if errors.Check(err) || len(resp.Allocations) != 2 {
+				t.Errorf("expected 2 allocations, got %v", err)
+			}
+		})
+	}
+}
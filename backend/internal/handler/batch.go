@@ -0,0 +1,289 @@
+package handler
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"mime"
+	"net/http"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/abdonasmane/etfs-simulator/backend/sdk/errors"
+)
+
+// Batch modes select the simulation an item runs.
+const (
+	batchModeYears  = "years"
+	batchModeTarget = "target"
+)
+
+const (
+	// maxBatchItems is the maximum number of items in a batch.
+	maxBatchItems = 1000
+
+	// maxBatchBodyBytes is the maximum size of a batch request body.
+	maxBatchBodyBytes = 16 << 20
+
+	// ndjsonContentType is the media type of newline-delimited JSON.
+	ndjsonContentType = "application/x-ndjson"
+)
+
+// --- Request Types ---
+
+// BatchItem is one simulation of a batch. A batch is a JSON array of items, or one item
+// per line when sent as application/x-ndjson.
+type BatchItem struct {
+	// ID is echoed in the item's result (e.g., a client reference).
+	ID string `json:"id,omitempty" example:"client-042"`
+
+	// Mode is "years" (default) for a SimulateByYearsRequest or "target" for a SimulateByTargetRequest.
+	Mode string `json:"mode,omitempty" example:"years"`
+
+	Request json.RawMessage `json:"request" swaggertype:"object"`
+}
+
+// --- Response Types ---
+
+// BatchResponse is the output for batch simulation. Results are in item order.
+type BatchResponse struct {
+	Results   []BatchResult `json:"results"`
+	Succeeded int           `json:"succeeded" example:"98"`
+	Failed    int           `json:"failed" example:"2"`
+}
+
+// BatchResult is the outcome of one batch item: a simulation response or an error.
+// NDJSON batches receive one result per line.
+type BatchResult struct {
+	Index int    `json:"index" example:"0"`
+	ID    string `json:"id,omitempty" example:"client-042"`
+
+	// Result is a SimulateByYearsResponse or a SimulateByTargetResponse, depending on the item's mode.
+	Result any    `json:"result,omitempty" swaggertype:"object"`
+	Error  string `json:"error,omitempty" example:"years must be between 1 and 50"`
+}
+
+// --- Handler ---
+
+// handleSimulateBatch runs many simulations with bounded concurrency.
+//
+//	@Summary		Batch simulation
+//	@Description	Runs up to 1000 simulation requests, sent as a JSON array or NDJSON, and returns a result or an error per item without failing the whole batch
+//	@Tags			simulation
+//	@Accept			json
+//	@Accept			application/x-ndjson
+//	@Produce		json
+//	@Produce		application/x-ndjson
+//	@Param			request	body		[]BatchItem	true	"Batch items"
+//	@Success		200		{object}	BatchResponse
+//	@Failure		400		{object}	ErrorResponse
+//	@Router			/api/v1/simulate/batch [post]
+func (h *Handler) handleSimulateBatch(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxBatchBodyBytes)
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	ndjson := mediaType == ndjsonContentType
+
+	var items []BatchItem
+	var results []BatchResult
+	if ndjson {
+		var err error
+		items, results, err = decodeNDJSONBatch(r)
+		if errors.Check(err) {
+			respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	} else {
+		if err := json.NewDecoder(r.Body).Decode(&items); errors.Check(err) {
+			respondError(w, http.StatusBadRequest, "invalid request body")
+			return
+		}
+		results = make([]BatchResult, len(items))
+	}
+
+	if len(items) == 0 || len(items) > maxBatchItems {
+		respondError(w, http.StatusBadRequest, fmt.Sprintf("batch must contain between 1 and %d items", maxBatchItems))
+		return
+	}
+
+	runs := h.prepareBatch(items, results, time.Now())
+	h.runBatch(r.Context(), runs, results, nil)
+	resp := newBatchResponse(results)
+
+	slog.Debug("batch simulation completed",
+		slog.Int("items", len(items)),
+		slog.Int("succeeded", resp.Succeeded),
+		slog.Int("failed", resp.Failed),
+		slog.Bool("ndjson", ndjson),
+	)
+
+	if !ndjson {
+		respondJSON(w, http.StatusOK, resp)
+		return
+	}
+
+	w.Header().Set("Content-Type", ndjsonContentType)
+	w.WriteHeader(http.StatusOK)
+	enc := json.NewEncoder(w)
+	for _, result := range results {
+		if err := enc.Encode(result); errors.Check(err) {
+			slog.Error("failed to encode NDJSON result", slog.String("error", err.Error()))
+			return
+		}
+	}
+}
+
+// --- Batch ---
+
+// decodeNDJSONBatch reads one item per non-empty line. Lines that are not valid items
+// become item errors rather than failing the batch.
+func decodeNDJSONBatch(r *http.Request) ([]BatchItem, []BatchResult, error) {
+	var items []BatchItem
+	var results []BatchResult
+
+	scanner := bufio.NewScanner(r.Body)
+	scanner.Buffer(make([]byte, 64<<10), maxBatchBodyBytes)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		var item BatchItem
+		result := BatchResult{Index: len(items)}
+		if err := json.Unmarshal(line, &item); errors.Check(err) {
+			result.Error = "invalid batch item"
+		}
+		items = append(items, item)
+		results = append(results, result)
+	}
+	if err := scanner.Err(); errors.Check(err) {
+		return nil, nil, errors.Wrap(err, "reading request body")
+	}
+
+	return items, results, nil
+}
+
+// batchRun runs a prepared batch item.
+type batchRun func(ctx context.Context) (any, error)
+
+// prepareBatch validates the items that have no error yet and returns their runs, in item
+// order. Items that fail validation get their error and no run.
+func (h *Handler) prepareBatch(items []BatchItem, results []BatchResult, now time.Time) []batchRun {
+	runs := make([]batchRun, len(items))
+	for i, item := range items {
+		results[i].Index = i
+		results[i].ID = item.ID
+		if results[i].Error != "" {
+			continue
+		}
+
+		run, err := h.prepareBatchItem(item, now)
+		if errors.Check(err) {
+			results[i].Error = err.Error()
+			continue
+		}
+		runs[i] = run
+	}
+	return runs
+}
+
+// runBatch runs the prepared items, at most GOMAXPROCS at a time. Items not started once
+// ctx is cancelled fail. done, if not nil, is called once for every item, whether it ran,
+// failed or was never started.
+func (h *Handler) runBatch(ctx context.Context, runs []batchRun, results []BatchResult, done func()) {
+	if done == nil {
+		done = func() {}
+	}
+
+	slots := make(chan struct{}, runtime.GOMAXPROCS(0))
+	var wg sync.WaitGroup
+
+	for i, run := range runs {
+		if run == nil {
+			done()
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			results[i].Error = "batch cancelled"
+			done()
+			continue
+		case slots <- struct{}{}:
+		}
+		wg.Go(func() {
+			defer func() { <-slots }()
+			defer done()
+			defer func() {
+				// A failing item must not take the whole batch down
+				if p := recover(); p != nil {
+					slog.Error("batch item panicked", slog.Int("index", i), slog.Any("panic", p))
+					results[i].Error = "internal error"
+				}
+			}()
+
+			// The slot may have been free when ctx was cancelled
+			if errors.Check(ctx.Err()) {
+				results[i].Error = "batch cancelled"
+				return
+			}
+
+			result, err := run(ctx)
+			if errors.Check(err) {
+				results[i].Error = err.Error()
+				return
+			}
+			results[i].Result = result
+		})
+	}
+
+	wg.Wait()
+}
+
+// newBatchResponse counts the succeeded and failed results of a batch.
+func newBatchResponse(results []BatchResult) BatchResponse {
+	resp := BatchResponse{Results: results}
+	for _, result := range results {
+		if result.Error != "" {
+			resp.Failed++
+		} else {
+			resp.Succeeded++
+		}
+	}
+	return resp
+}
+
+// prepareBatchItem decodes an item's request for its mode and prepares the simulation.
+func (h *Handler) prepareBatchItem(item BatchItem, now time.Time) (batchRun, error) {
+	switch item.Mode {
+	case "", batchModeYears:
+		var req SimulateByYearsRequest
+		if err := json.Unmarshal(item.Request, &req); errors.Check(err) {
+			return nil, errors.New("invalid request")
+		}
+		run, err := h.prepareYears(&req, now)
+		if errors.Check(err) {
+			return nil, err
+		}
+		return func(ctx context.Context) (any, error) { return run(ctx) }, nil
+
+	case batchModeTarget:
+		var req SimulateByTargetRequest
+		if err := json.Unmarshal(item.Request, &req); errors.Check(err) {
+			return nil, errors.New("invalid request")
+		}
+		run, err := h.prepareTarget(&req, now)
+		if errors.Check(err) {
+			return nil, err
+		}
+		return func(ctx context.Context) (any, error) { return run(ctx) }, nil
+
+	default:
+		return nil, errors.New("mode must be \"years\" or \"target\"")
+	}
+}
@@ -0,0 +1,90 @@
+package handler
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/abdonasmane/etfs-simulator/backend/sdk/errors"
+)
+
+// TestDecodeNDJSONBatch tests that blank lines are skipped and invalid lines become item errors.
+func TestDecodeNDJSONBatch(t *testing.T) {
+	body := `{"id":"a","request":{"years":10}}
+
+not json
+{"id":"c","mode":"target","request":{}}
+`
+	r := httptest.NewRequest("POST", "/api/v1/simulate/batch", strings.NewReader(body))
+
+	items, results, err := decodeNDJSONBatch(r)
+	if errors.Check(err) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(items) != 3 || len(results) != 3 {
+		t.Fatalf("expected 3 items, got %d items and %d results", len(items), len(results))
+	}
+	if items[0].ID != "a" || items[2].Mode != batchModeTarget {
+		t.Errorf("unexpected items: %+v", items)
+	}
+	if results[0].Error != "" || results[1].Error == "" || results[2].Error != "" {
+		t.Errorf("expected only the second line to fail, got %+v", results)
+	}
+}
+
+// TestPrepareBatchItemMode tests that unknown modes are rejected.
+func TestPrepareBatchItemMode(t *testing.T) {
+	h := &Handler{}
+	if _, err := h.prepareBatchItem(BatchItem{Mode: "monthly", Request: []byte(`{}`)}, time.Now()); !errors.Check(err) {
+		t.Error("expected an error for an unknown mode")
+	}
+	if _, err := h.prepareBatchItem(BatchItem{Request: []byte(`[]`)}, time.Now()); !errors.Check(err) {
+		t.Error("expected an error for an invalid request")
+	}
+}
+
+// TestPrepareBatch tests that invalid items fail when prepared and get no run.
+func TestPrepareBatch(t *testing.T) {
+	items := []BatchItem{
+		{ID: "a", Request: []byte(`{"years":10}`)},
+		{ID: "b", Request: []byte(`{"years":500}`)},
+		{ID: "c", Mode: batchModeTarget, Request: []byte(`{"targetYear":1999}`)},
+	}
+	results := make([]BatchResult, len(items))
+
+	h := &Handler{}
+	runs := h.prepareBatch(items, results, time.Now())
+
+	if runs[0] == nil || results[0].Error != "" {
+		t.Errorf("expected the first item to be prepared, got %+v", results[0])
+	}
+	for i := 1; i < len(items); i++ {
+		if runs[i] != nil || results[i].Error == "" || results[i].ID != items[i].ID || results[i].Index != i {
+			t.Errorf("expected item %d to fail when prepared, got %+v", i, results[i])
+		}
+	}
+}
+
+// TestRunBatchCancelled tests that items never started after cancellation fail and still count as done.
+func TestRunBatchCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	run := func(context.Context) (any, error) { return nil, nil }
+	runs := []batchRun{run, nil, run}
+	results := make([]BatchResult, len(runs))
+	results[1].Error = "invalid request"
+
+	var done int
+	h := &Handler{}
+	h.runBatch(ctx, runs, results, func() { done++ })
+
+	if done != len(runs) {
+		t.Errorf("expected done to be called %d times, got %d", len(runs), done)
+	}
+	if results[0].Error != "batch cancelled" || results[1].Error != "invalid request" || results[2].Error != "batch cancelled" {
+		t.Errorf("unexpected results: %+v", results)
+	}
+}
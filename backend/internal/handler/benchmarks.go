@@ -0,0 +1,124 @@
+package handler
+
+import "github.com/abdonasmane/etfs-simulator/backend/sdk/errors"
+
+// Benchmark names.
+const (
+	benchmarkCash      = "cash"
+	benchmarkSavings   = "savings"
+	benchmarkInflation = "inflation"
+)
+
+// BenchmarkOptions adds baselines holding the plan's contributions outside the market:
+// as cash, in a savings account and indexed to inflation.
+type BenchmarkOptions struct {
+	// SavingsRate is the annual interest percentage of the savings account (default: 2).
+	SavingsRate *float64 `json:"savingsRate,omitempty" example:"2"`
+
+	// InflationRate is the annual percentage the inflation baseline grows at (default: 2.5).
+	InflationRate *float64 `json:"inflationRate,omitempty" example:"2.5"`
+}
+
+// BenchmarkValues are the baseline values at the end of a month.
+type BenchmarkValues struct {
+	Cash      float64 `json:"cash" example:"61000.00"`
+	Savings   float64 `json:"savings" example:"67420.15"`
+	Inflation float64 `json:"inflation" example:"69810.40"`
+}
+
+// BenchmarkSummary compares the final (median) portfolio value with a baseline.
+type BenchmarkSummary struct {
+	Name string `json:"name" example:"savings"`
+
+	// Rate is the annual percentage the baseline grows at.
+	Rate       float64 `json:"rate" example:"2"`
+	FinalValue float64 `json:"finalValue" example:"67420.15"`
+
+	// ExcessValue is the portfolio value above the baseline, ExcessPercent its percentage of the baseline.
+	ExcessValue   float64 `json:"excessValue" example:"35180.93"`
+	ExcessPercent float64 `json:"excessPercent" example:"52.2"`
+}
+
+// benchmarkConfig holds the validated benchmark rates of a plan.
+type benchmarkConfig struct {
+	savings   float64
+	inflation float64
+}
+
+// applyBenchmarks validates the benchmark options and stores them on the plan.
+func applyBenchmarks(plan *simulationPlan, b *BenchmarkOptions) error {
+	if b == nil {
+		return nil
+	}
+
+	savings := applyDefault(b.SavingsRate, 2.0)
+	if savings < -5 || savings > 20 {
+		return errors.New("benchmarks.savingsRate must be between -5 and 20")
+	}
+	inflation := applyDefault(b.InflationRate, 2.5)
+	if inflation < 0 || inflation > 20 {
+		return errors.New("benchmarks.inflationRate must be between 0 and 20")
+	}
+	b.SavingsRate = &savings
+	b.InflationRate = &inflation
+
+	plan.benchmarks = &benchmarkConfig{savings: savings, inflation: inflation}
+	return nil
+}
+
+// addBenchmarks records the baseline values on each projection and compares the final values.
+// Baselines receive the same contributions as the portfolio and pay the same withdrawals, as
+// far as their balance allows.
+func addBenchmarks(plan *simulationPlan, projections []MonthProjection) []BenchmarkSummary {
+	b := plan.benchmarks
+	names := []string{benchmarkCash, benchmarkSavings, benchmarkInflation}
+	rates := []float64{0, b.savings, b.inflation}
+
+	monthly := make([]float64, len(rates))
+	balances := make([]float64, len(rates))
+	for k, r := range rates {
+		monthly[k] = annualToMonthly(r)
+		balances[k] = plan.initial
+	}
+
+	prevContributed := plan.initial
+	var prevWithdrawn float64
+	for i := range projections {
+		p := &projections[i]
+		contribution := p.TotalContributed - prevContributed
+		prevContributed = p.TotalContributed
+
+		var withdrawal float64
+		if p.TotalWithdrawn != nil {
+			withdrawal = *p.TotalWithdrawn - prevWithdrawn
+			prevWithdrawn = *p.TotalWithdrawn
+		}
+
+		for k := range balances {
+			balances[k] *= 1 + monthly[k]
+			balances[k] += contribution
+			balances[k] -= min(withdrawal, balances[k])
+		}
+
+		p.Benchmarks = &BenchmarkValues{
+			Cash:      round2(balances[0]),
+			Savings:   round2(balances[1]),
+			Inflation: round2(balances[2]),
+		}
+	}
+
+	finalValue := projections[len(projections)-1].PortfolioValue
+	summaries := make([]BenchmarkSummary, len(names))
+	for k, name := range names {
+		summaries[k] = BenchmarkSummary{
+			Name:        name,
+			Rate:        rates[k],
+			FinalValue:  round2(balances[k]),
+			ExcessValue: round2(finalValue - balances[k]),
+		}
+		if balances[k] > 0 {
+			summaries[k].ExcessPercent = round1((finalValue - balances[k]) / balances[k] * 100)
+		}
+	}
+	return summaries
+}
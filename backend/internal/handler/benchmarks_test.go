@@ -0,0 +1,35 @@
+package handler
+
+import "testing"
+
+// TestAddBenchmarks tests that baselines receive the same contributions and withdrawals.
+func TestAddBenchmarks(t *testing.T) {
+	plan := &simulationPlan{
+		initial:     1000,
+		monthlyBase: 100,
+		totalMonths: 3,
+		benchmarks:  &benchmarkConfig{savings: 12, inflation: 0},
+	}
+	withdrawn := []float64{0, 0, 1500}
+	projections := make([]MonthProjection, 3)
+	for i := range projections {
+		projections[i] = MonthProjection{TotalContributed: 1000 + 100*float64(i+1), PortfolioValue: 2000, TotalWithdrawn: &withdrawn[i]}
+	}
+
+	summaries := addBenchmarks(plan, projections)
+
+	// The cash baseline keeps 1200 until the withdrawal takes all of it
+	if projections[1].Benchmarks.Cash != 1200 || projections[2].Benchmarks.Cash != 0 {
+		t.Errorf("expected cash of 1200 then 0, got %.2f and %.2f", projections[1].Benchmarks.Cash, projections[2].Benchmarks.Cash)
+	}
+	if projections[1].Benchmarks.Savings <= 1200 {
+		t.Errorf("expected savings to earn interest, got %.2f", projections[1].Benchmarks.Savings)
+	}
+
+	if summaries[0].Name != benchmarkCash || summaries[0].ExcessValue != 2000 || summaries[0].ExcessPercent != 0 {
+		t.Errorf("unexpected cash summary: %+v", summaries[0])
+	}
+	if summaries[1].Rate != 12 {
+		t.Errorf("expected the savings rate, got %.2f", summaries[1].Rate)
+	}
+}
@@ -0,0 +1,156 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/abdonasmane/etfs-simulator/backend/sdk/errors"
+)
+
+// Cached endpoints, used in cache keys and metrics labels.
+const (
+	cacheEndpointYears       = "years"
+	cacheEndpointTarget      = "target"
+	cacheEndpointHousehold   = "household"
+	cacheEndpointDelay       = "delay"
+	cacheEndpointFees        = "fees"
+	cacheEndpointCompare     = "compare"
+	cacheEndpointSensitivity = "sensitivity"
+	cacheEndpointOptimize    = "optimize"
+	cacheEndpointAllocate    = "allocate"
+)
+
+// respondCached prepares a simulation and writes its response, serving it from the result
+// cache when available. A simulation is a pure function of its prepared request, the index
+// data and the start month, so its cache key doubles as a strong ETag: a matching
+// If-None-Match is answered with 304 Not Modified without simulating. Errors are not cached.
+// completed is called with each freshly simulated response.
+func respondCached[Req, Resp any](h *Handler, w http.ResponseWriter, r *http.Request, endpoint string, req *Req, prepare prepareFunc[Req, Resp], completed func(Resp)) {
+	now := time.Now()
+
+	run, err := prepare(req, now)
+	if errors.Check(err) {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	key, err := h.cacheKey(endpoint, req, now)
+	if errors.Check(err) {
+		respondError(w, http.StatusInternalServerError, "failed to encode request")
+		return
+	}
+	etag := `"` + key + `"`
+
+	if etagMatches(r.Header.Get("If-None-Match"), etag) {
+		h.metrics.CacheHit(endpoint)
+		w.Header().Set("ETag", etag)
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	if body, ok := h.results.Get(key); ok {
+		h.metrics.CacheHit(endpoint)
+		w.Header().Set("ETag", etag)
+		respondBody(w, body)
+		return
+	}
+	h.metrics.CacheMiss(endpoint)
+
+	resp, err := run(r.Context())
+	if errors.Check(err) {
+		respondRunError(w, r, endpoint, err)
+		return
+	}
+	completed(resp)
+
+	// Encoded like respondJSON so cached and fresh responses are identical
+	var body bytes.Buffer
+	if err := json.NewEncoder(&body).Encode(resp); errors.Check(err) {
+		slog.Error("failed to encode JSON response",
+			slog.String("error", err.Error()),
+		)
+		respondError(w, http.StatusInternalServerError, "failed to encode response")
+		return
+	}
+	h.results.Add(key, body.Bytes())
+
+	w.Header().Set("ETag", etag)
+	respondBody(w, body.Bytes())
+}
+
+// respondRunError writes the error of a prepared simulation. Requests are validated when
+// prepared, so run errors are data or internal errors. Nothing is written once the client
+// has gone.
+func respondRunError(w http.ResponseWriter, r *http.Request, endpoint string, err error) {
+	if errors.Check(r.Context().Err()) {
+		slog.Debug("simulation cancelled",
+			slog.String("endpoint", endpoint),
+			slog.String("error", err.Error()),
+		)
+		return
+	}
+
+	slog.Error("simulation failed",
+		slog.String("endpoint", endpoint),
+		slog.String("error", err.Error()),
+	)
+	if errors.Is(err, context.DeadlineExceeded) {
+		respondError(w, http.StatusServiceUnavailable, "simulation timed out")
+		return
+	}
+	respondError(w, http.StatusInternalServerError, err.Error())
+}
+
+// cacheKey hashes a prepared request with the endpoint, the index data version and the start
+// month. Requests are re-encoded once their defaults are applied, so formatting, field order,
+// unknown fields and explicit default values do not change the key.
+func (h *Handler) cacheKey(endpoint string, req any, now time.Time) (string, error) {
+	canonical, err := json.Marshal(req)
+	if errors.Check(err) {
+		return "", errors.Wrap(err, "encoding request")
+	}
+
+	hash := sha256.New()
+	hash.Write([]byte(endpoint))
+	hash.Write([]byte{0})
+	hash.Write([]byte(strconv.FormatUint(h.indexService.Version(), 10)))
+	hash.Write([]byte{0})
+	hash.Write([]byte(now.Format("2006-01")))
+	hash.Write([]byte{0})
+	hash.Write(canonical)
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// etagMatches reports whether an If-None-Match header matches etag. Weak validators
+// match their strong counterpart. The "*" wildcard is not honoured, as it would answer 304
+// to requests whose response the client has never received.
+func etagMatches(header, etag string) bool {
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if strings.TrimPrefix(tag, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// respondBody writes an encoded JSON response with status 200.
+func respondBody(w http.ResponseWriter, body []byte) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	if _, err := w.Write(body); errors.Check(err) {
+		slog.Error("failed to write JSON response",
+			slog.String("error", err.Error()),
+		)
+	}
+}
@@ -0,0 +1,151 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/abdonasmane/etfs-simulator/backend/internal/marketdata"
+	"github.com/abdonasmane/etfs-simulator/backend/sdk/errors"
+)
+
+// TestCacheKeyScope tests that cache keys differ by endpoint and start month, but not by day.
+func TestCacheKeyScope(t *testing.T) {
+	h := &Handler{indexService: marketdata.NewIndexService()}
+	now := time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC)
+	req := SimulateByYearsRequest{Years: 10}
+
+	key := func(endpoint string, now time.Time) string {
+		k, err := h.cacheKey(endpoint, req, now)
+		if errors.Check(err) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return k
+	}
+
+	if key(cacheEndpointYears, now) == key(cacheEndpointTarget, now) {
+		t.Error("expected endpoints to have different keys")
+	}
+	if key(cacheEndpointYears, now) == key(cacheEndpointYears, now.AddDate(0, 1, 0)) {
+		t.Error("expected start months to have different keys")
+	}
+	if key(cacheEndpointYears, now) != key(cacheEndpointYears, now.AddDate(0, 0, 5)) {
+		t.Error("expected days of the same month to share a key")
+	}
+}
+
+// TestCacheKeyPrepared tests that prepared requests share a key whatever their formatting
+// and whether defaults are explicit.
+func TestCacheKeyPrepared(t *testing.T) {
+	h := &Handler{indexService: marketdata.NewIndexService()}
+	now := time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC)
+
+	key := func(body string) string {
+		var req SimulateByYearsRequest
+		if err := json.Unmarshal([]byte(body), &req); errors.Check(err) {
+			t.Fatalf("invalid request: %v", err)
+		}
+		if _, err := h.prepareYears(&req, now); errors.Check(err) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		k, err := h.cacheKey(cacheEndpointYears, &req, now)
+		if errors.Check(err) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return k
+	}
+
+	base := key(`{"years":10}`)
+	if key(`{ "years": 10, "contributionGrowthRate": 0, "annualReturnRate": 7 }`) != base {
+		t.Error("expected explicit defaults to share a key")
+	}
+	if key(`{"years":10,"contributionGrowthRate":2}`) == base {
+		t.Error("expected different inputs to have different keys")
+	}
+}
+
+// TestETagMatches tests If-None-Match lists and weak validators, and that wildcards never match.
+func TestETagMatches(t *testing.T) {
+	tests := []struct {
+		header string
+		want   bool
+	}{
+		{`"abc"`, true},
+		{`"xyz", "abc"`, true},
+		{`W/"abc"`, true},
+		{`*`, false},
+		{`"xyz"`, false},
+		{``, false},
+	}
+
+	for _, tt := range tests {
+		if got := etagMatches(tt.header, `"abc"`); got != tt.want {
+			t.Errorf("etagMatches(%q): expected %v, got %v", tt.header, tt.want, got)
+		}
+	}
+}
+
+// TestRespondCached tests that identical requests are served from the cache, that a matching
+// ETag is answered with 304 and that errors are not cached.
+func TestRespondCached(t *testing.T) {
+	h := newTestHandler()
+	path := "/api/v1/simulate/years"
+
+	first := serve(h, path, `{"years":5,"indexSymbol":"SPY"}`, nil)
+	etag := first.Header().Get("ETag")
+	if first.Code != http.StatusOK || etag == "" {
+		t.Fatalf("expected 200 with an ETag, got %d %q", first.Code, etag)
+	}
+
+	// Replace the cached body to tell a hit from a fresh simulation
+	h.results.Add(strings.Trim(etag, `"`), []byte("{}\n"))
+	second := serve(h, path, `{"years":5,"indexSymbol":"SPY","contributionGrowthRate":0}`, nil)
+	if second.Code != http.StatusOK || second.Body.String() != "{}\n" || second.Header().Get("ETag") != etag {
+		t.Errorf("expected the identical request to be a cache hit, got %d %q", second.Code, second.Body.String())
+	}
+
+	notModified := serve(h, path, `{"years":5,"indexSymbol":"SPY"}`, http.Header{"If-None-Match": {etag}})
+	if notModified.Code != http.StatusNotModified || notModified.Body.Len() != 0 {
+		t.Errorf("expected 304 without a body, got %d", notModified.Code)
+	}
+	if wildcard := serve(h, path, `{"years":6}`, http.Header{"If-None-Match": {"*"}}); wildcard.Code != http.StatusOK {
+		t.Errorf("expected a wildcard not to match, got %d", wildcard.Code)
+	}
+	if invalid := serve(h, path, `{"years":0}`, http.Header{"If-None-Match": {"*"}}); invalid.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for an invalid request, got %d", invalid.Code)
+	}
+
+	// Historical windows need more history than a 30-year plan leaves, which is an invalid
+	// request, while missing history only fails once simulated
+	cached := h.results.Len()
+	tests := []struct {
+		body       string
+		wantStatus int
+	}{
+		{`{"years":30,"indexSymbol":"SPY","targetAmount":1000000,"probabilityMethod":"historical"}`, http.StatusBadRequest},
+		{`{"years":5,"indexSymbol":"` + testNoHistorySymbol + `","targetAmount":1000000}`, http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		failed := serve(h, path, tt.body, nil)
+		if failed.Code != tt.wantStatus || failed.Header().Get("ETag") != "" {
+			t.Errorf("%s: expected %d without an ETag, got %d", tt.body, tt.wantStatus, failed.Code)
+		}
+	}
+	if h.results.Len() != cached {
+		t.Error("expected errors not to be cached")
+	}
+
+	// Nothing is written once the client has gone
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	r := httptest.NewRequestWithContext(ctx, "POST", path, strings.NewReader(`{"years":7,"indexSymbol":"SPY","targetAmount":1000000}`))
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	if w.Body.Len() != 0 || w.Header().Get("Content-Type") != "" {
+		t.Errorf("expected no response to a cancelled request, got %d %q", w.Code, w.Body.String())
+	}
+}
@@ -0,0 +1,58 @@
+package handler
+
+import (
+	"github.com/abdonasmane/etfs-simulator/backend/internal/marketdata"
+	"github.com/abdonasmane/etfs-simulator/backend/sdk/errors"
+)
+
+// cashSymbol is the portfolio symbol of a cash or savings sleeve earning CashInterestRate.
+const cashSymbol = "CASH"
+
+// applyCashRate validates the cash interest rate and stores it on the plan.
+func applyCashRate(plan *simulationPlan, rate *float64) error {
+	if rate == nil {
+		return nil
+	}
+	if *rate < -5 || *rate > 20 {
+		return errors.New("cashInterestRate must be between -5 and 20")
+	}
+	plan.cashRate = rate
+	return nil
+}
+
+// lookupIndex returns the statistics of an index, or of the cash sleeve for cashSymbol.
+// The cash sleeve earns the same interest in every scenario and is priced at 1 per unit.
+func (h *Handler) lookupIndex(symbol string, cashRate *float64) (*marketdata.IndexInfo, error) {
+	if symbol != cashSymbol {
+		info, ok := h.indexService.GetIndex(symbol)
+		if !ok {
+			return nil, errors.New("unknown index symbol: " + symbol)
+		}
+		return info, nil
+	}
+
+	if cashRate == nil {
+		return nil, errors.New(cashSymbol + " requires cashInterestRate")
+	}
+	return &marketdata.IndexInfo{
+		Symbol:            cashSymbol,
+		Name:              "Cash",
+		Description:       "Cash or savings earning a fixed interest rate",
+		AssetClass:        marketdata.AssetClassCash,
+		ReturnBasis:       marketdata.ReturnBasisInterest,
+		MedianReturn:      *cashRate,
+		PessimisticReturn: *cashRate,
+		OptimisticReturn:  *cashRate,
+		LastPrice:         1,
+	}, nil
+}
+
+// onlyCash reports whether every allocation is the cash sleeve.
+func onlyCash(allocations []PortfolioAllocation) bool {
+	for _, a := range allocations {
+		if a.Symbol != cashSymbol {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,163 @@
+package handler
+
+import (
+	"math"
+
+	"github.com/abdonasmane/etfs-simulator/backend/sdk/errors"
+)
+
+// frequencyOptions are the purchase frequencies (in months) compared by the frequency analysis.
+var frequencyOptions = []int{1, 2, 3, 6, 12}
+
+// CommissionSchedule describes the broker fee charged on each purchase of an ETF.
+// The fee is Flat plus Percent of the trade amount, clamped to [Min, Max].
+type CommissionSchedule struct {
+	// Flat is a fixed fee per trade.
+	Flat float64 `json:"flat" example:"1.00"`
+
+	// Percent is a fee proportional to the trade amount (e.g., 0.1 for 0.1%).
+	Percent float64 `json:"percent" example:"0.1"`
+
+	// Min is the minimum fee per trade.
+	Min *float64 `json:"min,omitempty" example:"1.50"`
+
+	// Max is the maximum fee per trade.
+	Max *float64 `json:"max,omitempty" example:"15.00"`
+
+	// FreeTradesPerMonth is the number of commission-free trades each month.
+	FreeTradesPerMonth int `json:"freeTradesPerMonth" example:"0"`
+}
+
+// FrequencyAnalysis compares investing at different frequencies given the commission schedule.
+type FrequencyAnalysis struct {
+	Options []FrequencyOption `json:"options"`
+
+	// OptimalEveryMonths is the frequency maximizing the expected (median) final value.
+	OptimalEveryMonths int `json:"optimalEveryMonths" example:"3"`
+}
+
+// FrequencyOption is the expected outcome of investing every EveryMonths months.
+type FrequencyOption struct {
+	EveryMonths int     `json:"everyMonths" example:"3"`
+	Purchases   int     `json:"purchases" example:"40"`
+	FinalValue  float64 `json:"finalValue" example:"98420.15"`
+	TotalFees   float64 `json:"totalFees" example:"120.00"`
+
+	// FeeDrag is the total fees as a percentage of the amount contributed.
+	FeeDrag float64 `json:"feeDrag" example:"0.2"`
+}
+
+// validate checks the commission schedule.
+func (c *CommissionSchedule) validate() error {
+	if c.Flat < 0 {
+		return errors.New("commission.flat must be >= 0")
+	}
+	if c.Percent < 0 || c.Percent > 10 {
+		return errors.New("commission.percent must be between 0 and 10")
+	}
+	if c.Min != nil && *c.Min < 0 {
+		return errors.New("commission.min must be >= 0")
+	}
+	if c.Max != nil && *c.Max < 0 {
+		return errors.New("commission.max must be >= 0")
+	}
+	if c.Min != nil && c.Max != nil && *c.Max < *c.Min {
+		return errors.New("commission.max must be >= commission.min")
+	}
+	if c.FreeTradesPerMonth < 0 {
+		return errors.New("commission.freeTradesPerMonth must be >= 0")
+	}
+	return nil
+}
+
+// fee returns the commission for a trade of amount, where trade is the
+// 0-based index of the trade within its month. A nil schedule charges nothing.
+func (c *CommissionSchedule) fee(amount float64, trade int) float64 {
+	if c == nil || amount <= 0 || trade < c.FreeTradesPerMonth {
+		return 0
+	}
+
+	fee := c.Flat + amount*c.Percent/100
+	if c.Min != nil {
+		fee = math.Max(fee, *c.Min)
+	}
+	if c.Max != nil {
+		fee = math.Min(fee, *c.Max)
+	}
+	return fee
+}
+
+// purchaseFees returns the fees of splitting a purchase of amount between ETFs by weight,
+// one trade per ETF. Fees are paid out of the amount, so they never exceed it.
+func (c *CommissionSchedule) purchaseFees(amount float64, weights []float64) float64 {
+	if c == nil {
+		return 0
+	}
+
+	var fees float64
+	for trade, w := range weights {
+		fees += math.Min(c.fee(amount*w, trade), amount*w)
+	}
+	return fees
+}
+
+// applyCommission validates the commission and purchase frequency options and stores them on the plan.
+func applyCommission(plan *simulationPlan, commission *CommissionSchedule, investEvery *int) error {
+	if commission != nil {
+		if err := commission.validate(); errors.Check(err) {
+			return err
+		}
+		plan.commission = commission
+	}
+
+	plan.investEvery = 1
+	if investEvery != nil {
+		plan.investEvery = *investEvery
+	}
+	if plan.investEvery < 1 || plan.investEvery > 12 {
+		return errors.New("investEveryMonths must be between 1 and 12")
+	}
+
+	// Trades are split by target weight, or a single trade for a fixed-rate plan
+	plan.tradeWeights = []float64{1}
+	if len(plan.allocations) > 0 {
+		plan.tradeWeights = make([]float64, len(plan.allocations))
+		for i, a := range plan.allocations {
+			plan.tradeWeights[i] = a.Weight / 100
+		}
+	}
+
+	return nil
+}
+
+// analyzeFrequencies runs the median path at each purchase frequency and picks the best one.
+func analyzeFrequencies(plan *simulationPlan) *FrequencyAnalysis {
+	analysis := &FrequencyAnalysis{Options: make([]FrequencyOption, 0, len(frequencyOptions))}
+
+	best := -1.0
+	for _, every := range frequencyOptions {
+		variant := *plan
+		variant.investEvery = every
+
+		run := runEngine(&variant, variant.scenarioPath(scenarioMedian), false)
+		final := run.values[len(run.values)-1]
+
+		option := FrequencyOption{
+			EveryMonths: every,
+			Purchases:   run.purchases,
+			FinalValue:  round2(final),
+			TotalFees:   round2(run.totalFees),
+		}
+		if run.totalContributed > 0 {
+			option.FeeDrag = round2(run.totalFees / run.totalContributed * 100)
+		}
+		analysis.Options = append(analysis.Options, option)
+
+		if final > best {
+			best = final
+			analysis.OptimalEveryMonths = every
+		}
+	}
+
+	return analysis
+}
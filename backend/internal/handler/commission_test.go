@@ -0,0 +1,57 @@
+package handler
+
+import (
+	"math"
+	"testing"
+)
+
+// TestCommissionFee tests the flat, percentage, min/max and free trade rules.
+func TestCommissionFee(t *testing.T) {
+	minFee, maxFee := 2.0, 10.0
+	c := &CommissionSchedule{Flat: 1, Percent: 0.5, Min: &minFee, Max: &maxFee, FreeTradesPerMonth: 1}
+
+	tests := []struct {
+		amount float64
+		trade  int
+		want   float64
+	}{
+		{amount: 500, trade: 0, want: 0},    // free trade
+		{amount: 100, trade: 1, want: 2},    // 1.50 raised to the minimum
+		{amount: 1000, trade: 1, want: 6},   // 1 + 0.5%
+		{amount: 10000, trade: 2, want: 10}, // 51 capped at the maximum
+		{amount: 0, trade: 3, want: 0},      // nothing bought
+	}
+	for _, tt := range tests {
+		if got := c.fee(tt.amount, tt.trade); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("fee(%.0f, %d) = %.2f, want %.2f", tt.amount, tt.trade, got, tt.want)
+		}
+	}
+
+	var none *CommissionSchedule
+	if got := none.fee(1000, 5); got != 0 {
+		t.Errorf("nil schedule should be free, got %.2f", got)
+	}
+}
+
+// TestFrequencyAnalysisFlatFee tests that a large flat fee favors investing less often.
+func TestFrequencyAnalysisFlatFee(t *testing.T) {
+	plan := &simulationPlan{
+		monthlyBase:  100,
+		annualRate:   7,
+		startYear:    2025,
+		startMonth:   1,
+		totalMonths:  120,
+		commission:   &CommissionSchedule{Flat: 10},
+		tradeWeights: []float64{1},
+	}
+
+	analysis := analyzeFrequencies(plan)
+	if analysis.OptimalEveryMonths == 1 {
+		t.Errorf("a $10 fee on $100 monthly purchases should not favor monthly investing")
+	}
+
+	monthly := analysis.Options[0]
+	if monthly.Purchases != 120 || math.Abs(monthly.TotalFees-1200) > 1e-9 {
+		t.Errorf("expected 120 purchases and 1200 in fees, got %d and %.2f", monthly.Purchases, monthly.TotalFees)
+	}
+}
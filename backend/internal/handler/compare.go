@@ -0,0 +1,262 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/abdonasmane/etfs-simulator/backend/sdk/errors"
+)
+
+const (
+	// minCompareScenarios and maxCompareScenarios bound the scenarios of a comparison.
+	minCompareScenarios = 2
+	maxCompareScenarios = 10
+)
+
+// --- Request Types ---
+
+// CompareRequest is the input for comparing plans side by side.
+type CompareRequest struct {
+	// Years is the number of years every scenario is simulated for (1-50).
+	Years int `json:"years" example:"20"`
+
+	Scenarios []CompareScenario `json:"scenarios"`
+}
+
+// CompareScenario is a named plan to compare. Granularity and Fields are ignored: projections
+// are always aligned month by month.
+type CompareScenario struct {
+	Name string `json:"name" example:"All-in S&P 500"`
+
+	SimulationInputs
+}
+
+// --- Response Types ---
+
+// CompareResponse is the output for scenario comparison.
+type CompareResponse struct {
+	Inputs CompareRequest `json:"inputs"`
+
+	// Timeline holds every scenario's values for each month, in scenario order.
+	Timeline []ComparePoint `json:"timeline"`
+
+	// Comparison ranks the scenarios by final (median) value, in scenario order.
+	Comparison []ScenarioComparison `json:"comparison"`
+}
+
+// ComparePoint is the value of each scenario at the end of a month.
+type ComparePoint struct {
+	Year   int             `json:"year" example:"2030"`
+	Month  int             `json:"month" example:"6"`
+	Values []ScenarioValue `json:"values"`
+}
+
+// ScenarioValue is a scenario's value at the end of a month.
+type ScenarioValue struct {
+	Name             string  `json:"name" example:"All-in S&P 500"`
+	PortfolioValue   float64 `json:"portfolioValue" example:"48210.30"`
+	TotalContributed float64 `json:"totalContributed" example:"31000"`
+
+	// Range values (only present when IndexSymbol or Portfolio is provided)
+	PessimisticValue *float64 `json:"pessimisticValue,omitempty" example:"42100.00"`
+	OptimisticValue  *float64 `json:"optimisticValue,omitempty" example:"55320.00"`
+}
+
+// ScenarioComparison compares a scenario's results with the others.
+type ScenarioComparison struct {
+	Name string `json:"name" example:"All-in S&P 500"`
+
+	// Rank orders scenarios by final value, 1 being the highest.
+	Rank int `json:"rank" example:"1"`
+
+	FinalValue       float64  `json:"finalValue" example:"245300.10"`
+	TotalContributed float64  `json:"totalContributed" example:"121000"`
+	TotalGain        float64  `json:"totalGain" example:"124300.10"`
+	PercentageGain   float64  `json:"percentageGain" example:"102.7"`
+	PessimisticValue *float64 `json:"pessimisticValue,omitempty" example:"180200.00"`
+	OptimisticValue  *float64 `json:"optimisticValue,omitempty" example:"330450.00"`
+
+	// DifferenceFromBest is the final value minus the best scenario's (0 for the best).
+	DifferenceFromBest float64 `json:"differenceFromBest" example:"-12040.55"`
+
+	// Summary is the scenario's full simulation summary.
+	Summary SimulateSummary `json:"summary"`
+}
+
+// --- Handler ---
+
+// handleSimulateCompare runs several named plans and compares them.
+//
+//	@Summary		Compare scenarios
+//	@Description	Simulates 2 to 10 named plans concurrently over the same period and returns aligned projections with a ranked comparison table
+//	@Tags			simulation
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		CompareRequest	true	"Scenarios to compare"
+//	@Param			If-None-Match	header		string	false	"ETag of a previous identical request"
+//	@Success		200		{object}	CompareResponse
+//	@Header			200		{string}	ETag	"Validator for If-None-Match"
+//	@Success		304		"Not Modified"
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		500		{object}	ErrorResponse
+//	@Router			/api/v1/simulate/compare [post]
+func (h *Handler) handleSimulateCompare(w http.ResponseWriter, r *http.Request) {
+	var req CompareRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); errors.Check(err) {
+		respondError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	respondCached(h, w, r, cacheEndpointCompare, &req, h.prepareCompare, func(resp *CompareResponse) {
+		slog.Debug("scenario comparison completed",
+			slog.Int("scenarios", len(resp.Comparison)),
+			slog.Int("years", resp.Inputs.Years),
+		)
+	})
+}
+
+// --- Comparison ---
+
+// prepareCompare validates a comparison request and builds every scenario's plan. Its run
+// simulates the scenarios concurrently, reporting progress per scenario, and scenarios not
+// yet run once ctx is cancelled fail.
+func (h *Handler) prepareCompare(req *CompareRequest, now time.Time) (func(context.Context) (*CompareResponse, error), error) {
+	if req.Years < 1 || req.Years > 50 {
+		return nil, errors.New("years must be between 1 and 50")
+	}
+	if len(req.Scenarios) < minCompareScenarios || len(req.Scenarios) > maxCompareScenarios {
+		return nil, errors.Errorf("scenarios must contain between %d and %d scenarios", minCompareScenarios, maxCompareScenarios)
+	}
+
+	names := make(map[string]bool, len(req.Scenarios))
+	for _, s := range req.Scenarios {
+		if s.Name == "" {
+			return nil, errors.New("scenario name is required")
+		}
+		if names[s.Name] {
+			return nil, errors.New("duplicate scenario name: " + s.Name)
+		}
+		names[s.Name] = true
+	}
+
+	startYear := now.Year()
+	startMonth := int(now.Month())
+	totalMonths := req.Years * 12
+	endYear := startYear + req.Years
+
+	plans := make([]*simulationPlan, len(req.Scenarios))
+	for i := range req.Scenarios {
+		s := &req.Scenarios[i]
+		plan, err := h.newPlan(&s.SimulationInputs, startYear, startMonth, totalMonths, endYear, startMonth)
+		if errors.Check(err) {
+			return nil, errors.Wrap(err, "scenario "+s.Name)
+		}
+		plan.aggregate = false
+		plans[i] = plan
+	}
+
+	return func(ctx context.Context) (*CompareResponse, error) {
+		// Each scenario only touches its own plan and result. Progress is reported
+		// per scenario rather than per probability path.
+		results := make([]*simulationResult, len(plans))
+		errs := make([]error, len(plans))
+		scenarioCtx := withProgress(ctx, nil)
+		var completed atomic.Int64
+		var wg sync.WaitGroup
+		for i, plan := range plans {
+			wg.Go(func() {
+				if err := ctx.Err(); errors.Check(err) {
+					errs[i] = err
+					return
+				}
+
+				var err error
+				results[i], err = h.runPlan(scenarioCtx, plan)
+				if errors.Check(err) {
+					errs[i] = errors.Wrap(err, "scenario "+req.Scenarios[i].Name)
+					return
+				}
+				reportProgress(ctx, int(completed.Add(1)), len(plans))
+			})
+		}
+		wg.Wait()
+
+		for _, err := range errs {
+			if errors.Check(err) {
+				return nil, err
+			}
+		}
+
+		return &CompareResponse{
+			Inputs:     *req,
+			Timeline:   alignScenarios(req.Scenarios, results),
+			Comparison: compareScenarios(req.Scenarios, results),
+		}, nil
+	}, nil
+}
+
+// alignScenarios merges the scenarios' monthly projections into a single timeline.
+func alignScenarios(scenarios []CompareScenario, results []*simulationResult) []ComparePoint {
+	timeline := make([]ComparePoint, len(results[0].projections))
+	for m := range timeline {
+		first := results[0].projections[m]
+		point := ComparePoint{Year: first.Year, Month: first.Month, Values: make([]ScenarioValue, len(results))}
+		for i, result := range results {
+			p := result.projections[m]
+			point.Values[i] = ScenarioValue{
+				Name:             scenarios[i].Name,
+				PortfolioValue:   p.PortfolioValue,
+				TotalContributed: p.TotalContributed,
+				PessimisticValue: p.PessimisticValue,
+				OptimisticValue:  p.OptimisticValue,
+			}
+		}
+		timeline[m] = point
+	}
+	return timeline
+}
+
+// compareScenarios builds the comparison table, ranking scenarios by final value.
+func compareScenarios(scenarios []CompareScenario, results []*simulationResult) []ScenarioComparison {
+	comparison := make([]ScenarioComparison, len(results))
+	for i, result := range results {
+		s := result.summary
+		comparison[i] = ScenarioComparison{
+			Name:             scenarios[i].Name,
+			FinalValue:       s.FinalValue,
+			TotalContributed: s.TotalContributed,
+			TotalGain:        s.TotalGain,
+			PercentageGain:   s.PercentageGain,
+			PessimisticValue: s.PessimisticValue,
+			OptimisticValue:  s.OptimisticValue,
+			Summary:          s,
+		}
+	}
+
+	order := make([]int, len(comparison))
+	for i := range order {
+		order[i] = i
+	}
+	slices.SortStableFunc(order, func(a, b int) int {
+		switch {
+		case comparison[a].FinalValue > comparison[b].FinalValue:
+			return -1
+		case comparison[a].FinalValue < comparison[b].FinalValue:
+			return 1
+		}
+		return 0
+	})
+
+	best := comparison[order[0]].FinalValue
+	for rank, i := range order {
+		comparison[i].Rank = rank + 1
+		comparison[i].DifferenceFromBest = round2(comparison[i].FinalValue - best)
+	}
+	return comparison
+}
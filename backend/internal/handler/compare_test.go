@@ -0,0 +1,130 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/abdonasmane/etfs-simulator/backend/sdk/errors"
+)
+
+// TestCompareScenarios tests that scenarios are ranked by final value in input order.
+func TestCompareScenarios(t *testing.T) {
+	scenarios := []CompareScenario{{Name: "low"}, {Name: "high"}, {Name: "mid"}}
+	results := []*simulationResult{
+		{summary: SimulateSummary{FinalValue: 100}},
+		{summary: SimulateSummary{FinalValue: 300}},
+		{summary: SimulateSummary{FinalValue: 200}},
+	}
+
+	comparison := compareScenarios(scenarios, results)
+
+	wantRanks := []int{3, 1, 2}
+	wantDiffs := []float64{-200, 0, -100}
+	for i, c := range comparison {
+		if c.Name != scenarios[i].Name || c.Rank != wantRanks[i] || c.DifferenceFromBest != wantDiffs[i] {
+			t.Errorf("scenario %d: expected rank %d and difference %.0f, got %+v", i, wantRanks[i], wantDiffs[i], c)
+		}
+	}
+}
+
+// TestHandleSimulateCompare tests that scenarios with different return sources and
+// contribution periods are aligned month by month and match their comparison.
+func TestHandleSimulateCompare(t *testing.T) {
+	// The second scenario retires, and stops contributing, after two years
+	body := fmt.Sprintf(`{"years":5,"scenarios":[
+		{"name":"index","indexSymbol":"SPY","monthlyContribution":500},
+		{"name":"fixed","annualReturnRate":5,"monthlyContribution":500,"birthYear":%d,"birthMonth":1,"retirementAge":60}
+	]}`, time.Now().Year()-58)
+
+	w := serve(newTestHandler(), "/api/v1/simulate/compare", body, nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp CompareResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); errors.Check(err) {
+		t.Fatalf("invalid response: %v", err)
+	}
+
+	if len(resp.Timeline) != 60 {
+		t.Fatalf("expected 60 months, got %d", len(resp.Timeline))
+	}
+	year, month := resp.Timeline[0].Year, resp.Timeline[0].Month
+	for m, p := range resp.Timeline {
+		if p.Year != year || p.Month != month {
+			t.Fatalf("month %d: expected %d-%02d, got %d-%02d", m, year, month, p.Year, p.Month)
+		}
+		year, month = nextMonth(year, month)
+
+		if len(p.Values) != 2 || p.Values[0].Name != "index" || p.Values[1].Name != "fixed" {
+			t.Fatalf("month %d: expected values in scenario order, got %+v", m, p.Values)
+		}
+		if p.Values[0].PessimisticValue == nil || p.Values[1].PessimisticValue != nil {
+			t.Errorf("month %d: expected a range only for the index scenario", m)
+		}
+	}
+
+	last := resp.Timeline[len(resp.Timeline)-1]
+	for i, c := range resp.Comparison {
+		if math.Abs(last.Values[i].PortfolioValue-c.FinalValue) > 0.01 {
+			t.Errorf("%s: expected the timeline to end at the final value %.2f, got %.2f", c.Name, c.FinalValue, last.Values[i].PortfolioValue)
+		}
+	}
+	if index, fixed := last.Values[0].TotalContributed, last.Values[1].TotalContributed; fixed >= index {
+		t.Errorf("expected the retired scenario to contribute less, got %.2f and %.2f", fixed, index)
+	}
+}
+
+// TestHandleSimulateCompareErrors tests that an invalid scenario is a bad request and a
+// failing one an internal error, both naming the scenario.
+func TestHandleSimulateCompareErrors(t *testing.T) {
+	h := newTestHandler()
+
+	tests := []struct {
+		name     string
+		scenario string
+		wantCode int
+	}{
+		{"invalid", `{"name":"broken","indexSymbol":"UNKNOWN"}`, http.StatusBadRequest},
+		{"failed", `{"name":"broken","indexSymbol":"` + testNoHistorySymbol + `","targetAmount":100000}`, http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := `{"years":5,"scenarios":[{"name":"ok","indexSymbol":"SPY"},` + tt.scenario + `]}`
+
+			w := serve(h, "/api/v1/simulate/compare", body, nil)
+			if w.Code != tt.wantCode {
+				t.Fatalf("expected %d, got %d: %s", tt.wantCode, w.Code, w.Body.String())
+			}
+			var resp ErrorResponse
+			if err := json.NewDecoder(w.Body).Decode(&resp); errors.Check(err) {
+				t.Fatalf("invalid response: %v", err)
+			}
+			if !strings.HasPrefix(resp.Error, "scenario broken: ") {
+				t.Errorf("expected the error to name the scenario, got %q", resp.Error)
+			}
+		})
+	}
+}
+
+// TestPrepareCompareCancelled tests that a cancelled comparison fails without results.
+func TestPrepareCompareCancelled(t *testing.T) {
+	req := CompareRequest{Years: 5, Scenarios: []CompareScenario{{Name: "a"}, {Name: "b"}}}
+	run, err := newTestHandler().prepareCompare(&req, time.Now())
+	if errors.Check(err) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if resp, err := run(ctx); !errors.Is(err, context.Canceled) || resp != nil {
+		t.Errorf("expected the cancellation error, got %v", err)
+	}
+}
@@ -0,0 +1,110 @@
+package handler
+
+import (
+	"math"
+
+	"github.com/abdonasmane/etfs-simulator/backend/sdk/errors"
+)
+
+// Cost basis methods.
+const (
+	costBasisFIFO    = "fifo"
+	costBasisAverage = "average"
+)
+
+// taxLot is a quantity of units bought together and what they cost, commissions included.
+type taxLot struct {
+	units float64
+	cost  float64
+}
+
+// costLedger tracks the tax lots of each position during a run. A position is an ETF in
+// share mode, or the whole portfolio (as units of its value) otherwise.
+//
+// With the average method each position holds a single lot at the average cost, so
+// selling releases basis proportionally. With FIFO the oldest lots are sold first.
+type costLedger struct {
+	method    string
+	positions [][]taxLot
+}
+
+// newCostLedger creates an empty ledger for the given number of positions.
+func newCostLedger(method string, positions int) *costLedger {
+	return &costLedger{method: method, positions: make([][]taxLot, positions)}
+}
+
+// buy records a purchase of units for cost.
+func (l *costLedger) buy(position int, units, cost float64) {
+	if units <= 0 {
+		return
+	}
+
+	lots := l.positions[position]
+	if l.method == costBasisAverage && len(lots) > 0 {
+		lots[0].units += units
+		lots[0].cost += cost
+		return
+	}
+	l.positions[position] = append(lots, taxLot{units: units, cost: cost})
+}
+
+// sell removes units from a position and returns the cost basis released.
+func (l *costLedger) sell(position int, units float64) float64 {
+	var released float64
+	lots := l.positions[position]
+	for len(lots) > 0 && units > 1e-12 {
+		lot := &lots[0]
+		if units >= lot.units {
+			released += lot.cost
+			units -= lot.units
+			lots = lots[1:]
+			continue
+		}
+
+		cost := lot.cost * units / lot.units
+		released += cost
+		lot.cost -= cost
+		lot.units -= units
+		units = 0
+	}
+	l.positions[position] = lots
+	return released
+}
+
+// basis returns the total cost basis of all open lots.
+func (l *costLedger) basis() float64 {
+	var total float64
+	for _, lots := range l.positions {
+		for _, lot := range lots {
+			total += lot.cost
+		}
+	}
+	return total
+}
+
+// liquidationTax returns the capital-gains tax due if every position were sold.
+// Losses offset gains; a net loss owes nothing.
+func liquidationTax(unrealizedGain, taxRate float64) float64 {
+	return math.Max(0, unrealizedGain) * taxRate / 100
+}
+
+// applyCostBasis validates the cost basis options and stores them on the plan.
+func applyCostBasis(plan *simulationPlan, method *string, taxRate *float64) error {
+	plan.costBasisMethod = costBasisFIFO
+	if method != nil && *method != "" {
+		plan.costBasisMethod = *method
+	}
+	if plan.costBasisMethod != costBasisFIFO && plan.costBasisMethod != costBasisAverage {
+		return errors.New("costBasisMethod must be \"fifo\" or \"average\"")
+	}
+
+	if taxRate != nil {
+		if *taxRate < 0 || *taxRate > 100 {
+			return errors.New("capitalGainsTaxRate must be between 0 and 100")
+		}
+		plan.taxRate = taxRate
+	}
+	plan.reportCostBasis = (method != nil && *method != "") || taxRate != nil
+
+	return nil
+}
@@ -0,0 +1,86 @@
+package handler
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"testing"
+
+	"github.com/abdonasmane/etfs-simulator/backend/sdk/errors"
+)
+
+// TestCostLedgerSell tests that FIFO sells the oldest lots first and average cost sells proportionally.
+func TestCostLedgerSell(t *testing.T) {
+	tests := []struct {
+		method   string
+		released float64
+	}{
+		{method: costBasisFIFO, released: 100 + 5*20},       // all of the first lot, half of the second
+		{method: costBasisAverage, released: 15 * 300 / 20}, // 15 of 20 units at 15 each
+	}
+
+	for _, tt := range tests {
+		ledger := newCostLedger(tt.method, 1)
+		ledger.buy(0, 10, 100) // 10 units at 10
+		ledger.buy(0, 10, 200) // 10 units at 20
+
+		released := ledger.sell(0, 15)
+		if math.Abs(released-tt.released) > 1e-9 {
+			t.Errorf("%s: expected %.2f basis released, got %.2f", tt.method, tt.released, released)
+		}
+		if math.Abs(ledger.basis()-(300-tt.released)) > 1e-9 {
+			t.Errorf("%s: expected %.2f basis left, got %.2f", tt.method, 300-tt.released, ledger.basis())
+		}
+	}
+}
+
+// TestLiquidationTax tests that only a net gain is taxed.
+func TestLiquidationTax(t *testing.T) {
+	if got := liquidationTax(1000, 30); math.Abs(got-300) > 1e-9 {
+		t.Errorf("expected 300, got %.2f", got)
+	}
+	if got := liquidationTax(-500, 30); got != 0 {
+		t.Errorf("a loss should owe no tax, got %.2f", got)
+	}
+}
+
+// TestCostBasisReported tests that cost basis fields are only returned when a cost basis
+// method or a capital gains tax rate is requested.
+func TestCostBasisReported(t *testing.T) {
+	h := newTestHandler()
+
+	tests := []struct {
+		name    string
+		options string
+		want    bool
+	}{
+		{"default", ``, false},
+		{"method", `,"costBasisMethod":"average"`, true},
+		{"tax rate", `,"capitalGainsTaxRate":30`, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(h, "/api/v1/simulate/years", `{"years":2,"initialInvestment":1000,"monthlyContribution":100`+tt.options+`}`, nil)
+			if w.Code != http.StatusOK {
+				t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+			}
+
+			var resp struct {
+				Inputs      map[string]json.RawMessage   `json:"inputs"`
+				Projections []map[string]json.RawMessage `json:"projections"`
+				Summary     map[string]json.RawMessage   `json:"summary"`
+			}
+			if err := json.NewDecoder(w.Body).Decode(&resp); errors.Check(err) {
+				t.Fatalf("invalid response: %v", err)
+			}
+
+			_, method := resp.Inputs["costBasisMethod"]
+			_, projected := resp.Projections[len(resp.Projections)-1]["unrealizedGain"]
+			_, summarized := resp.Summary["costBasis"]
+			if method != tt.want || projected != tt.want || summarized != tt.want {
+				t.Errorf("expected cost basis fields to be present: %v, got method %v, projections %v and summary %v", tt.want, method, projected, summarized)
+			}
+		})
+	}
+}
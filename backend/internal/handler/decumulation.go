@@ -0,0 +1,216 @@
+package handler
+
+import (
+	"math"
+
+	"github.com/abdonasmane/etfs-simulator/backend/sdk/errors"
+)
+
+// Income stream types.
+var incomeStreamTypes = map[string]bool{
+	"pension":        true,
+	"socialSecurity": true,
+	"annuity":        true,
+	"rental":         true,
+	"other":          true,
+}
+
+// maxIncomeStreams is the maximum number of income streams in a plan.
+const maxIncomeStreams = 10
+
+// DecumulationPlan withdraws retirement spending from the portfolio once RetirementAge is reached.
+// Income streams active in retirement reduce what has to be withdrawn.
+type DecumulationPlan struct {
+	// MonthlySpending is the spending need in today's money.
+	MonthlySpending float64 `json:"monthlySpending" example:"3000"`
+
+	// InflationRate indexes spending and, by default, income streams (default: 2.5).
+	InflationRate *float64 `json:"inflationRate,omitempty" example:"2.5"`
+
+	IncomeStreams []IncomeStream `json:"incomeStreams,omitempty"`
+}
+
+// IncomeStream is an external income received from StartAge until EndAge (exclusive).
+type IncomeStream struct {
+	Name string `json:"name" example:"State pension"`
+
+	// Type is "pension", "socialSecurity", "annuity", "rental" or "other".
+	Type string `json:"type" example:"pension"`
+
+	// MonthlyAmount is the monthly income in today's money.
+	MonthlyAmount float64 `json:"monthlyAmount" example:"1200"`
+
+	StartAge int  `json:"startAge" example:"67"`
+	EndAge   *int `json:"endAge,omitempty" example:"90"`
+
+	// IndexationRate is the annual increase of the income (default: the plan's InflationRate, 0 for a fixed amount).
+	IndexationRate *float64 `json:"indexationRate,omitempty" example:"2.5"`
+}
+
+// DecumulationSummary compares portfolio draws with external income during retirement.
+type DecumulationSummary struct {
+	Yearly []DrawdownYear `json:"yearly"`
+
+	TotalSpending       float64 `json:"totalSpending" example:"412000.00"`
+	TotalExternalIncome float64 `json:"totalExternalIncome" example:"168500.00"`
+	TotalWithdrawn      float64 `json:"totalWithdrawn" example:"243500.00"`
+
+	// TotalShortfall is the spending neither income nor the portfolio could cover.
+	TotalShortfall float64 `json:"totalShortfall" example:"0"`
+
+	// DepletedAt is the first month the portfolio could not cover the spending (omitted if never).
+	DepletedAt *MilestoneDate `json:"depletedAt,omitempty"`
+}
+
+// DrawdownYear is the retirement spending of a calendar year and how it was funded.
+type DrawdownYear struct {
+	Year           int     `json:"year" example:"2046"`
+	Age            *int    `json:"age,omitempty" example:"61"`
+	Spending       float64 `json:"spending" example:"43200.00"`
+	ExternalIncome float64 `json:"externalIncome" example:"0"`
+	PortfolioDraw  float64 `json:"portfolioDraw" example:"43200.00"`
+	Shortfall      float64 `json:"shortfall" example:"0"`
+	EndValue       float64 `json:"endValue" example:"398210.55"`
+}
+
+// decumulationConfig holds the validated, defaulted decumulation options of a plan.
+type decumulationConfig struct {
+	spending  float64
+	inflation float64
+	streams   []IncomeStream
+}
+
+// monthWithdrawal is the retirement spending of one month and how it is funded,
+// plus any one-off amount taken out for a goal.
+type monthWithdrawal struct {
+	spending float64
+	income   float64
+	goal     float64
+}
+
+// applyDecumulation validates the decumulation options and stores them on the plan.
+func applyDecumulation(plan *simulationPlan, d *DecumulationPlan) error {
+	if d == nil {
+		return nil
+	}
+
+	if plan.retirementAge == nil {
+		return errors.New("decumulation requires retirementAge")
+	}
+	if d.MonthlySpending < 0 {
+		return errors.New("decumulation.monthlySpending must be >= 0")
+	}
+
+	inflation := applyDefault(d.InflationRate, 2.5)
+	if inflation < 0 || inflation > 20 {
+		return errors.New("decumulation.inflationRate must be between 0 and 20")
+	}
+	d.InflationRate = &inflation
+
+	if len(d.IncomeStreams) > maxIncomeStreams {
+		return errors.Errorf("at most %d income streams are allowed", maxIncomeStreams)
+	}
+	for i := range d.IncomeStreams {
+		s := &d.IncomeStreams[i]
+		if !incomeStreamTypes[s.Type] {
+			return errors.New("incomeStream type must be pension, socialSecurity, annuity, rental or other")
+		}
+		if s.MonthlyAmount < 0 {
+			return errors.New("incomeStream monthlyAmount must be >= 0")
+		}
+		if s.StartAge < 0 || s.StartAge > 120 {
+			return errors.New("incomeStream startAge must be between 0 and 120")
+		}
+		if s.EndAge != nil && *s.EndAge <= s.StartAge {
+			return errors.New("incomeStream endAge must be greater than startAge")
+		}
+
+		indexation := applyDefault(s.IndexationRate, inflation)
+		if indexation < 0 || indexation > 20 {
+			return errors.New("incomeStream indexationRate must be between 0 and 20")
+		}
+		s.IndexationRate = &indexation
+	}
+
+	plan.decumulation = &decumulationConfig{
+		spending:  d.MonthlySpending,
+		inflation: inflation,
+		streams:   d.IncomeStreams,
+	}
+	return nil
+}
+
+// withdrawalSchedule returns the spending, external income and goal withdrawals of each
+// month of the plan. Months before retirement have no spending.
+func (p *simulationPlan) withdrawalSchedule() []monthWithdrawal {
+	schedule := make([]monthWithdrawal, p.totalMonths)
+	for i, amount := range p.goalWithdrawals {
+		schedule[i].goal = amount
+	}
+	if p.decumulation == nil {
+		return schedule
+	}
+
+	d := p.decumulation
+	year, month := p.startYear, p.startMonth
+	for i := range schedule {
+		year, month = nextMonth(year, month)
+		if !p.retired(year, month) {
+			continue
+		}
+
+		// Amounts are in today's money and indexed from the start of the simulation
+		years := float64(i+1) / 12
+		schedule[i].spending = d.spending * math.Pow(1+d.inflation/100, years)
+
+		age := p.ageAt(year, month)
+		for _, s := range d.streams {
+			if age < s.StartAge || (s.EndAge != nil && age >= *s.EndAge) {
+				continue
+			}
+			schedule[i].income += s.MonthlyAmount * math.Pow(1+*s.IndexationRate/100, years)
+		}
+	}
+	return schedule
+}
+
+// buildDecumulationSummary groups retirement months by calendar year.
+func buildDecumulationSummary(projections []MonthProjection) *DecumulationSummary {
+	summary := &DecumulationSummary{Yearly: []DrawdownYear{}}
+
+	for i, p := range projections {
+		if p.Spending == nil {
+			continue
+		}
+
+		spending, income, draw, shortfall := *p.Spending, *p.ExternalIncome, *p.Withdrawal, *p.Shortfall
+
+		n := len(summary.Yearly)
+		if n == 0 || summary.Yearly[n-1].Year != p.Year {
+			summary.Yearly = append(summary.Yearly, DrawdownYear{Year: p.Year})
+			n++
+		}
+		y := &summary.Yearly[n-1]
+		y.Age = p.Age
+		y.Spending = round2(y.Spending + spending)
+		y.ExternalIncome = round2(y.ExternalIncome + income)
+		y.PortfolioDraw = round2(y.PortfolioDraw + draw)
+		y.Shortfall = round2(y.Shortfall + shortfall)
+		y.EndValue = p.PortfolioValue
+
+		summary.TotalSpending += spending
+		summary.TotalExternalIncome += income
+		summary.TotalWithdrawn += draw
+		summary.TotalShortfall += shortfall
+
+		if shortfall > 0 && summary.DepletedAt == nil {
+			summary.DepletedAt = &MilestoneDate{Year: p.Year, Month: p.Month, MonthsFromNow: i + 1, Age: p.Age}
+		}
+	}
+
+	summary.TotalSpending = round2(summary.TotalSpending)
+	summary.TotalExternalIncome = round2(summary.TotalExternalIncome)
+	summary.TotalWithdrawn = round2(summary.TotalWithdrawn)
+	summary.TotalShortfall = round2(summary.TotalShortfall)
+	return summary
+}
@@ -0,0 +1,67 @@
+package handler
+
+import (
+	"math"
+	"testing"
+)
+
+// TestWithdrawalSchedule tests that spending starts at retirement and income streams start at their age.
+func TestWithdrawalSchedule(t *testing.T) {
+	retirementAge := 60
+	fixed := 0.0
+	plan := &simulationPlan{
+		startYear:     2025,
+		startMonth:    12,
+		totalMonths:   36,
+		birthYear:     1966,
+		birthMonth:    1,
+		retirementAge: &retirementAge,
+		decumulation: &decumulationConfig{
+			spending:  2000,
+			inflation: 0,
+			streams:   []IncomeStream{{Type: "pension", MonthlyAmount: 500, StartAge: 61, IndexationRate: &fixed}},
+		},
+	}
+
+	schedule := plan.withdrawalSchedule()
+
+	// January 2026 is the 60th birthday, January 2027 the 61st
+	if schedule[0].spending != 2000 || schedule[0].income != 0 {
+		t.Errorf("expected 2000 spending and no income at 60, got %.2f and %.2f", schedule[0].spending, schedule[0].income)
+	}
+	if schedule[12].income != 500 {
+		t.Errorf("expected the pension to start at 61, got %.2f", schedule[12].income)
+	}
+}
+
+// TestRunEngineWithdrawals tests that withdrawals reduce the portfolio and stop when it is depleted.
+func TestRunEngineWithdrawals(t *testing.T) {
+	retirementAge := 60
+	plan := &simulationPlan{
+		initial:       5000,
+		annualRate:    0,
+		startYear:     2025,
+		startMonth:    12,
+		totalMonths:   4,
+		birthYear:     1966,
+		birthMonth:    1,
+		retirementAge: &retirementAge,
+		decumulation:  &decumulationConfig{spending: 2000},
+	}
+
+	run := runEngine(plan, plan.scenarioPath(scenarioMedian), true)
+	want := []float64{3000, 1000, 0, 0}
+	for i, p := range run.projections {
+		if math.Abs(p.PortfolioValue-want[i]) > 1e-9 {
+			t.Errorf("month %d: expected %.0f, got %.2f", i, want[i], p.PortfolioValue)
+		}
+	}
+
+	summary := buildDecumulationSummary(run.projections)
+	if summary.TotalWithdrawn != 5000 || summary.TotalShortfall != 3000 {
+		t.Errorf("expected 5000 withdrawn and 3000 shortfall, got %.2f and %.2f", summary.TotalWithdrawn, summary.TotalShortfall)
+	}
+	if summary.DepletedAt == nil || summary.DepletedAt.MonthsFromNow != 3 {
+		t.Errorf("expected depletion in the third month, got %+v", summary.DepletedAt)
+	}
+}
@@ -0,0 +1,258 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/abdonasmane/etfs-simulator/backend/sdk/errors"
+)
+
+// Delay modes.
+const (
+	delayModeSameEndDate  = "sameEndDate"
+	delayModeSameDuration = "sameDuration"
+)
+
+const (
+	// maxDelayYears is the longest delay analyzed.
+	maxDelayYears = 20
+
+	// maxCatchUpContribution bounds the search for a catch-up contribution.
+	maxCatchUpContribution = 1e7
+)
+
+// --- Request Types ---
+
+// CostOfDelayRequest is the input for analyzing the cost of starting a plan later.
+type CostOfDelayRequest struct {
+	SimulationInputs
+
+	// Years is the duration of the plan started now (1-50).
+	Years int `json:"years" example:"30"`
+
+	// MaxDelayYears is the longest delay analyzed; every delay from 1 year up to it is reported.
+	MaxDelayYears int `json:"maxDelayYears" example:"5"`
+
+	// Mode is "sameEndDate" (default), where delayed plans end on the same date and run shorter,
+	// or "sameDuration", where delayed plans run for Years and end later.
+	Mode *string `json:"mode,omitempty" example:"sameEndDate"`
+}
+
+// --- Response Types ---
+
+// CostOfDelayResponse is the output for cost-of-delay analysis.
+type CostOfDelayResponse struct {
+	Inputs CostOfDelayRequest `json:"inputs"`
+
+	// Baseline is the plan started now.
+	Baseline DelayScenario   `json:"baseline"`
+	Delays   []DelayScenario `json:"delays"`
+}
+
+// DelayScenario is the outcome of the plan started after a delay.
+type DelayScenario struct {
+	DelayYears int `json:"delayYears" example:"2"`
+	StartYear  int `json:"startYear" example:"2027"`
+	StartMonth int `json:"startMonth" example:"10"`
+	EndYear    int `json:"endYear" example:"2055"`
+	EndMonth   int `json:"endMonth" example:"10"`
+
+	TotalContributed float64 `json:"totalContributed" example:"169000"`
+	FinalValue       float64 `json:"finalValue" example:"512300.40"`
+
+	// Range values (only present when IndexSymbol or Portfolio is provided)
+	PessimisticValue *float64 `json:"pessimisticValue,omitempty" example:"380120.00"`
+	OptimisticValue  *float64 `json:"optimisticValue,omitempty" example:"690450.00"`
+
+	// Gap is the baseline's final (median) value minus this one, GapPercent its percentage of the baseline.
+	Gap        float64 `json:"gap" example:"88200.15"`
+	GapPercent float64 `json:"gapPercent" example:"14.7"`
+
+	// CatchUpContribution is the extra monthly contribution that closes the gap (omitted when
+	// no contribution can, e.g. when contributions stop at retirement soon after the start).
+	CatchUpContribution *float64 `json:"catchUpContribution,omitempty" example:"142.50"`
+}
+
+// --- Handler ---
+
+// handleCostOfDelay analyzes what delaying the start of a plan costs.
+//
+//	@Summary		Cost of delay
+//	@Description	Runs the same plan started now and delayed by 1 to maxDelayYears years, and reports the final value gap and the extra monthly contribution needed to catch up
+//	@Tags			simulation
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		CostOfDelayRequest	true	"Plan and delay parameters"
+//	@Param			If-None-Match	header		string	false	"ETag of a previous identical request"
+//	@Success		200		{object}	CostOfDelayResponse
+//	@Header			200		{string}	ETag	"Validator for If-None-Match"
+//	@Success		304		"Not Modified"
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		500		{object}	ErrorResponse
+//	@Router			/api/v1/simulate/delay [post]
+func (h *Handler) handleCostOfDelay(w http.ResponseWriter, r *http.Request) {
+	var req CostOfDelayRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); errors.Check(err) {
+		respondError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	respondCached(h, w, r, cacheEndpointDelay, &req, h.prepareCostOfDelay, func(resp *CostOfDelayResponse) {
+		slog.Debug("cost of delay completed",
+			slog.Int("years", resp.Inputs.Years),
+			slog.Int("max_delay_years", resp.Inputs.MaxDelayYears),
+			slog.String("mode", *resp.Inputs.Mode),
+			slog.Float64("final_value", resp.Baseline.FinalValue),
+		)
+	})
+}
+
+// --- Analysis ---
+
+// prepareCostOfDelay validates a cost-of-delay request and builds the plan for each delay.
+// Its run simulates every plan, reporting progress per delay, and stops if ctx is cancelled.
+func (h *Handler) prepareCostOfDelay(req *CostOfDelayRequest, now time.Time) (func(context.Context) (*CostOfDelayResponse, error), error) {
+	if req.Years < 1 || req.Years > 50 {
+		return nil, errors.New("years must be between 1 and 50")
+	}
+
+	mode := delayModeSameEndDate
+	if req.Mode != nil && *req.Mode != "" {
+		mode = *req.Mode
+	}
+	req.Mode = &mode
+
+	switch mode {
+	case delayModeSameEndDate:
+		if req.MaxDelayYears < 1 || req.MaxDelayYears >= req.Years || req.MaxDelayYears > maxDelayYears {
+			return nil, errors.Errorf("maxDelayYears must be between 1 and %d, and less than years", maxDelayYears)
+		}
+	case delayModeSameDuration:
+		if req.MaxDelayYears < 1 || req.MaxDelayYears > maxDelayYears {
+			return nil, errors.Errorf("maxDelayYears must be between 1 and %d", maxDelayYears)
+		}
+	default:
+		return nil, errors.New("mode must be \"sameEndDate\" or \"sameDuration\"")
+	}
+
+	startYear := now.Year()
+	startMonth := int(now.Month())
+
+	// plans[0] is the baseline, started now
+	plans := make([]*simulationPlan, req.MaxDelayYears+1)
+	for delay := range plans {
+		plan, err := h.delayedPlan(req, startYear, startMonth, delay, mode)
+		if errors.Check(err) {
+			return nil, err
+		}
+		plans[delay] = plan
+	}
+
+	return func(ctx context.Context) (*CostOfDelayResponse, error) {
+		baseline := plans[0]
+		baselineRun := runEngine(baseline, baseline.scenarioPath(scenarioMedian), false)
+		target := baselineRun.values[baseline.totalMonths-1]
+
+		resp := &CostOfDelayResponse{
+			Inputs:   *req,
+			Baseline: delayScenario(baseline, 0, baselineRun, target),
+			Delays:   make([]DelayScenario, 0, req.MaxDelayYears),
+		}
+
+		for delay := 1; delay <= req.MaxDelayYears; delay++ {
+			if err := ctx.Err(); errors.Check(err) {
+				return nil, err
+			}
+
+			plan := plans[delay]
+			run := runEngine(plan, plan.scenarioPath(scenarioMedian), false)
+			scenario := delayScenario(plan, delay, run, target)
+			scenario.CatchUpContribution = catchUpContribution(plan, target)
+			resp.Delays = append(resp.Delays, scenario)
+			reportProgress(ctx, delay, req.MaxDelayYears)
+		}
+
+		return resp, nil
+	}, nil
+}
+
+// delayedPlan builds the plan started delay years after startYear/startMonth.
+func (h *Handler) delayedPlan(req *CostOfDelayRequest, startYear, startMonth, delay int, mode string) (*simulationPlan, error) {
+	totalMonths := req.Years * 12
+	endYear := startYear + req.Years
+	if mode == delayModeSameEndDate {
+		totalMonths -= delay * 12
+	} else {
+		endYear += delay
+	}
+
+	return h.newPlan(&req.SimulationInputs, startYear+delay, startMonth, totalMonths, endYear, startMonth)
+}
+
+// delayScenario summarizes a plan's median run and its range against the baseline's final value.
+func delayScenario(plan *simulationPlan, delay int, run engineResult, target float64) DelayScenario {
+	final := run.values[plan.totalMonths-1]
+	scenario := DelayScenario{
+		DelayYears:       delay,
+		StartYear:        plan.startYear,
+		StartMonth:       plan.startMonth,
+		EndYear:          plan.endYear,
+		EndMonth:         plan.endMonth,
+		TotalContributed: round2(run.totalContributed),
+		FinalValue:       round2(final),
+		Gap:              round2(target - final),
+	}
+	if target > 0 {
+		scenario.GapPercent = round1((target - final) / target * 100)
+	}
+
+	if plan.rates != nil {
+		pessimistic := runEngine(plan, plan.scenarioPath(scenarioPessimistic), false).values[plan.totalMonths-1]
+		optimistic := runEngine(plan, plan.scenarioPath(scenarioOptimistic), false).values[plan.totalMonths-1]
+		pessimistic, optimistic = round2(pessimistic), round2(optimistic)
+		scenario.PessimisticValue = &pessimistic
+		scenario.OptimisticValue = &optimistic
+	}
+
+	return scenario
+}
+
+// catchUpContribution searches for the smallest extra monthly contribution with which the
+// plan's median final value reaches target. It returns nil if no contribution is enough.
+func catchUpContribution(plan *simulationPlan, target float64) *float64 {
+	finalWith := func(extra float64) float64 {
+		variant := *plan
+		variant.extraContribution = extra
+		return runEngine(&variant, variant.scenarioPath(scenarioMedian), false).values[plan.totalMonths-1]
+	}
+
+	if finalWith(0) >= target {
+		zero := 0.0
+		return &zero
+	}
+
+	// Double the upper bound until it is enough, then bisect to the cent
+	low, high := 0.0, 100.0
+	for finalWith(high) < target {
+		low = high
+		high *= 2
+		if high > maxCatchUpContribution {
+			return nil
+		}
+	}
+	for high-low > 0.005 {
+		mid := (low + high) / 2
+		if finalWith(mid) >= target {
+			high = mid
+		} else {
+			low = mid
+		}
+	}
+
+	extra := math.Ceil(high*100) / 100
+	return &extra
+}
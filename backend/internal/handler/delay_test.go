@@ -0,0 +1,27 @@
+package handler
+
+import "testing"
+
+// TestCatchUpContribution tests the extra contribution needed to reach a target.
+func TestCatchUpContribution(t *testing.T) {
+	plan := &simulationPlan{
+		initial:     1000,
+		monthlyBase: 100,
+		startYear:   2025,
+		startMonth:  12,
+		totalMonths: 12,
+	}
+
+	// At 0% the plan ends at 2200, so 1200 more takes 100 a month
+	extra := catchUpContribution(plan, 3400)
+	if extra == nil || *extra != 100 {
+		t.Errorf("expected a catch-up of 100, got %v", extra)
+	}
+
+	if extra := catchUpContribution(plan, 2000); extra == nil || *extra != 0 {
+		t.Errorf("expected no catch-up when already reached, got %v", extra)
+	}
+	if extra := catchUpContribution(plan, 1e12); extra != nil {
+		t.Errorf("expected no catch-up for an unreachable target, got %.2f", *extra)
+	}
+}
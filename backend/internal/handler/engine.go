@@ -0,0 +1,404 @@
+package handler
+
+import "math"
+
+// scenario selects which set of return rates a deterministic run uses.
+type scenario int
+
+const (
+	scenarioMedian scenario = iota
+	scenarioPessimistic
+	scenarioOptimistic
+)
+
+// returnPath is the sequence of monthly returns (decimals) a simulation run follows.
+type returnPath struct {
+	// portfolio is the blended portfolio return for each month.
+	portfolio []float64
+
+	// symbols holds each allocation's own return for each month ([month][symbol]).
+	// Only needed when shares are tracked per symbol.
+	symbols [][]float64
+}
+
+// engineResult holds the output of a single run of the simulation engine.
+type engineResult struct {
+	// projections is only populated when the run is recorded.
+	projections []MonthProjection
+
+	// values is the unrounded portfolio value at the end of each month.
+	values []float64
+
+	// initialValue is the final value of the initial investment on its own,
+	// including its share of any withdrawals.
+	initialValue float64
+
+	// crossoverMonth is the index of the first month whose investment growth
+	// exceeded that month's contribution, or -1 if it never did.
+	crossoverMonth int
+
+	// crossoverGrowth is the investment growth during the crossover month.
+	crossoverGrowth float64
+
+	// totalContributed includes the initial investment.
+	totalContributed float64
+
+	// totalFees is the commission paid on all purchases, and purchases their number.
+	totalFees float64
+	purchases int
+}
+
+// annualRateFor returns the blended annual rate (percent) of a scenario.
+func (p *simulationPlan) annualRateFor(s scenario) float64 {
+	if p.rates == nil {
+		return p.annualRate
+	}
+
+	switch s {
+	case scenarioPessimistic:
+		return p.rates.pessimistic
+	case scenarioOptimistic:
+		return p.rates.optimistic
+	default:
+		return p.rates.median
+	}
+}
+
+// symbolRatesFor returns each allocation's own annual rate (percent) for a scenario.
+func (p *simulationPlan) symbolRatesFor(s scenario) []float64 {
+	rates := make([]float64, len(p.allocations))
+	for i := range p.allocations {
+		r := p.rates
+		if p.portfolio != nil {
+			r = &p.portfolio.symbolRates[i]
+		}
+
+		switch s {
+		case scenarioPessimistic:
+			rates[i] = r.pessimistic
+		case scenarioOptimistic:
+			rates[i] = r.optimistic
+		default:
+			rates[i] = r.median
+		}
+	}
+	return rates
+}
+
+// scenarioPath builds the constant-rate return path of a deterministic scenario.
+func (p *simulationPlan) scenarioPath(s scenario) returnPath {
+	path := returnPath{portfolio: constantReturns(p.annualRateFor(s), p.totalMonths)}
+
+	if p.shares != nil {
+		symbolRates := p.symbolRatesFor(s)
+		monthly := make([]float64, len(symbolRates))
+		for i, r := range symbolRates {
+			monthly[i] = annualToMonthly(r)
+		}
+
+		path.symbols = make([][]float64, p.totalMonths)
+		for m := range path.symbols {
+			path.symbols[m] = monthly
+		}
+	}
+
+	return path
+}
+
+// annualToMonthly converts an annual percentage rate into the equivalent monthly decimal rate.
+func annualToMonthly(annualRate float64) float64 {
+	return math.Pow(1+annualRate/100, 1.0/12.0) - 1
+}
+
+// constantReturns builds a return path that applies the same annual rate every month.
+func constantReturns(annualRate float64, months int) []float64 {
+	monthlyReturnRate := annualToMonthly(annualRate)
+
+	returns := make([]float64, months)
+	for i := range returns {
+		returns[i] = monthlyReturnRate
+	}
+	return returns
+}
+
+// runEngine simulates a plan month by month along a return path.
+// When record is false only the end-of-month values are kept, which keeps
+// Monte Carlo style callers cheap.
+func runEngine(plan *simulationPlan, path returnPath, record bool) engineResult {
+	contributions := plan.contributionSchedule()
+	withdrawals := plan.withdrawalSchedule()
+
+	result := engineResult{
+		values:         make([]float64, 0, plan.totalMonths),
+		crossoverMonth: -1,
+	}
+	if record {
+		result.projections = make([]MonthProjection, 0, plan.totalMonths)
+	}
+
+	// Share mode tracks shares per symbol, otherwise the portfolio is rebalanced every month
+	// and tracked as units of a fund whose unit price starts at 1
+	var book *shareBook
+	ledger := newCostLedger(plan.costBasisMethod, 1)
+	if plan.shares != nil {
+		ledger = newCostLedger(plan.costBasisMethod, len(plan.shares.symbols))
+		book = newShareBook(plan.shares)
+		book.commission = plan.commission
+		book.ledger = ledger
+	}
+
+	// Accounts are only tracked for recorded runs
+	var accounts *accountBook
+	if record && plan.accounts != nil {
+		accounts = newAccountBook(plan.accounts)
+	}
+
+	// Employer money is tracked on its own to report what is not vested yet
+	var employer sleeve
+
+	var invested float64 // market value of the blended portfolio (without share mode)
+	var pending float64  // contributions waiting for the next purchase
+	unitPrice := 1.0
+	current := func() float64 {
+		if book != nil {
+			return book.holdingsValue() + book.cash + pending
+		}
+		return invested + pending
+	}
+	purchase := func(amount float64) {
+		if amount <= 0 {
+			return
+		}
+		var fees float64
+		if book != nil {
+			fees = book.invest(amount)
+		} else {
+			fees = plan.commission.purchaseFees(amount, plan.tradeWeights)
+			invested += amount - fees
+			ledger.buy(0, (amount-fees)/unitPrice, amount)
+		}
+		if accounts != nil {
+			accounts.purchase(amount, fees)
+		}
+		employer.purchase(1 - fees/amount)
+		result.totalFees += fees
+		result.purchases++
+	}
+
+	// initialBalance follows the initial investment on its own
+	var initialBalance, initialWithdrawn float64
+
+	// withdraw sells an equal fraction of everything held, capped at the portfolio value,
+	// and returns the amount withdrawn
+	withdraw := func(amount float64) float64 {
+		total := current()
+		amount = math.Min(amount, total)
+		if amount <= 0 {
+			return 0
+		}
+
+		ratio := amount / total
+		fromPending := pending * ratio
+		pending -= fromPending
+		if book != nil {
+			book.withdraw(amount - fromPending)
+		} else {
+			sold := amount - fromPending
+			ledger.sell(0, sold/unitPrice)
+			invested -= sold
+		}
+		if accounts != nil {
+			accounts.withdraw(ratio)
+		}
+		employer.withdraw(ratio)
+		initialWithdrawn += initialBalance * ratio
+		initialBalance *= 1 - ratio
+		return amount
+	}
+
+	// The initial amount is invested right away, contributions every investEvery months
+	investEvery := max(plan.investEvery, 1)
+	if accounts != nil {
+		accounts.contribute(plan.initial, plan.startYear)
+	}
+	purchase(plan.initial)
+
+	initialBalance = current()
+	totalContributed := plan.initial
+	var totalWithdrawn float64
+
+	currentYear := plan.startYear
+	currentMonth := plan.startMonth
+
+	// Service towards vesting stops at retirement
+	var serviceMonths int
+	if record && plan.salary != nil {
+		serviceMonths = plan.retirementMonth()
+	}
+
+	for i := 0; i < plan.totalMonths; i++ {
+		// Advance to next month
+		currentMonth++
+		if currentMonth > 12 {
+			currentMonth = 1
+			currentYear++
+		}
+
+		c := contributions[i]
+		currentContribution := c.employee + c.employer
+
+		// Apply investment return (uninvested cash earns nothing)
+		base := current() - pending
+		var growth float64
+		if book != nil {
+			growth = book.applyReturns(path.symbols[i])
+		} else {
+			growth = invested * path.portfolio[i]
+			invested += growth
+			unitPrice *= 1 + path.portfolio[i]
+		}
+		if base > 0 {
+			initialBalance *= (1 + growth/base)
+			if accounts != nil {
+				accounts.grow(growth / base)
+			}
+			employer.grow(growth / base)
+		}
+
+		if result.crossoverMonth < 0 && growth > currentContribution {
+			result.crossoverMonth = i
+			result.crossoverGrowth = growth
+		}
+
+		// Add contribution and invest pending cash on purchase months
+		pending += currentContribution
+		employer.contribute(c.employer)
+		totalContributed += currentContribution
+		if accounts != nil {
+			accounts.contribute(currentContribution, currentYear)
+		}
+		if (i+1)%investEvery == 0 {
+			purchase(pending)
+			pending = 0
+		}
+
+		// Withdraw the retirement spending not covered by external income
+		w := withdrawals[i]
+		var withdrawn float64
+		if w.spending > 0 {
+			withdrawn = withdraw(math.Max(0, w.spending-w.income))
+			totalWithdrawn += withdrawn
+		}
+		if w.goal > 0 {
+			totalWithdrawn += withdraw(w.goal)
+		}
+
+		balance := current()
+		result.values = append(result.values, balance)
+		if record {
+			cash := pending
+			if book != nil {
+				cash += book.cash
+			}
+			basis := ledger.basis()
+			unrealizedGain := balance - cash - basis
+
+			projection := MonthProjection{
+				Year:                currentYear,
+				Month:               currentMonth,
+				MonthlyContribution: round2(currentContribution),
+				TotalContributed:    round2(totalContributed),
+				PortfolioValue:      round2(balance),
+				Age:                 plan.agePtr(currentYear, currentMonth),
+			}
+			if plan.reportCostBasis {
+				costBasis, gain := round2(basis), round2(unrealizedGain)
+				projection.CostBasis = &costBasis
+				projection.UnrealizedGain = &gain
+			}
+			if book != nil {
+				book.record(&projection)
+			}
+			if accounts != nil {
+				accounts.record(&projection)
+			}
+			if book != nil || investEvery > 1 {
+				cash = round2(cash)
+				projection.Cash = &cash
+			}
+			if plan.taxRate != nil {
+				tax := round2(liquidationTax(unrealizedGain, *plan.taxRate))
+				projection.LiquidationTax = &tax
+			}
+			if plan.commission != nil {
+				fees := round2(result.totalFees)
+				projection.FeesPaid = &fees
+			}
+			if plan.salary != nil {
+				salary := round2(c.salary)
+				employee := round2(c.employee)
+				employerContribution := round2(c.employer)
+				employerValue := round2(employer.value())
+				unvested := round2(employer.value() * (1 - plan.salary.vestedPercent(min(i+1, serviceMonths))/100))
+				projection.Salary = &salary
+				projection.EmployeeContribution = &employee
+				projection.EmployerContribution = &employerContribution
+				projection.EmployerValue = &employerValue
+				projection.UnvestedValue = &unvested
+			}
+			if plan.decumulation != nil || len(plan.goalWithdrawals) > 0 {
+				total := round2(totalWithdrawn)
+				projection.TotalWithdrawn = &total
+			}
+			if plan.decumulation != nil && plan.retired(currentYear, currentMonth) {
+				spending := round2(w.spending)
+				income := round2(w.income)
+				withdrawal := round2(withdrawn)
+				shortfall := round2(math.Max(0, w.spending-w.income-withdrawn))
+				projection.Spending = &spending
+				projection.ExternalIncome = &income
+				projection.Withdrawal = &withdrawal
+				projection.Shortfall = &shortfall
+			}
+			result.projections = append(result.projections, projection)
+		}
+	}
+
+	result.initialValue = initialBalance + initialWithdrawn
+	result.totalContributed = totalContributed
+	return result
+}
+
+// sleeve tracks the value of one source of money within the portfolio. It receives
+// the portfolio's growth on what it has invested and its share of purchase fees.
+type sleeve struct {
+	invested float64
+	pending  float64
+}
+
+// contribute adds money waiting for the next purchase.
+func (s *sleeve) contribute(amount float64) {
+	s.pending += amount
+}
+
+// purchase invests the pending money, keeping the net fraction left after fees.
+func (s *sleeve) purchase(net float64) {
+	s.invested += s.pending * net
+	s.pending = 0
+}
+
+// grow applies one month of growth as a ratio of the invested value.
+func (s *sleeve) grow(ratio float64) {
+	s.invested *= 1 + ratio
+}
+
+// withdraw removes the same fraction of invested and pending money.
+func (s *sleeve) withdraw(ratio float64) {
+	s.invested *= 1 - ratio
+	s.pending *= 1 - ratio
+}
+
+// value returns the sleeve's invested and pending money.
+func (s *sleeve) value() float64 {
+	return s.invested + s.pending
+}
@@ -0,0 +1,112 @@
+package handler
+
+// ResultExplanation breaks down where the final value of the median path comes from.
+type ResultExplanation struct {
+	// Decomposition splits the final value by source.
+	Decomposition GrowthDecomposition `json:"decomposition"`
+
+	// Yearly lists contributions versus investment gains for each calendar year.
+	Yearly []YearlyGains `json:"yearly"`
+
+	// Crossover is the first month where investment growth exceeded the monthly contribution.
+	// Omitted if growth never overtakes contributions within the simulation.
+	Crossover *CrossoverPoint `json:"crossover,omitempty"`
+}
+
+// GrowthDecomposition splits the final value into what was invested and what it earned.
+// The four parts, minus any withdrawals, add up to the final value.
+type GrowthDecomposition struct {
+	InitialInvestment     float64 `json:"initialInvestment" example:"10000"`
+	Contributions         float64 `json:"contributions" example:"60000"`
+	GrowthOnInitial       float64 `json:"growthOnInitial" example:"9672.20"`
+	GrowthOnContributions float64 `json:"growthOnContributions" example:"22928.88"`
+
+	// Withdrawals is the total taken out in retirement (only present when Decumulation is provided).
+	Withdrawals float64 `json:"withdrawals,omitempty" example:"0"`
+
+	// GrowthShare is the percentage of the final value (plus withdrawals) that comes from investment growth.
+	GrowthShare float64 `json:"growthShare" example:"31.8"`
+}
+
+// YearlyGains compares contributions and investment gains within a calendar year.
+type YearlyGains struct {
+	Year                  int     `json:"year" example:"2030"`
+	Contributions         float64 `json:"contributions" example:"6000"`
+	Gains                 float64 `json:"gains" example:"4120.55"`
+	EndValue              float64 `json:"endValue" example:"62450.10"`
+	CumulativeContributed float64 `json:"cumulativeContributed" example:"46000"`
+	CumulativeGains       float64 `json:"cumulativeGains" example:"16450.10"`
+}
+
+// CrossoverPoint is the month where monthly investment growth first exceeds the monthly contribution.
+type CrossoverPoint struct {
+	Year                int     `json:"year" example:"2038"`
+	Month               int     `json:"month" example:"4"`
+	MonthsFromNow       int     `json:"monthsFromNow" example:"150"`
+	MonthlyGrowth       float64 `json:"monthlyGrowth" example:"512.40"`
+	MonthlyContribution float64 `json:"monthlyContribution" example:"500"`
+}
+
+// explainResult builds the explanation of the median path from its projections.
+func explainResult(plan *simulationPlan, projections []MonthProjection) *ResultExplanation {
+	run := runEngine(plan, plan.scenarioPath(scenarioMedian), false)
+
+	final := projections[len(projections)-1]
+	contributions := final.TotalContributed - plan.initial
+	var withdrawals float64
+	if final.TotalWithdrawn != nil {
+		withdrawals = *final.TotalWithdrawn
+	}
+	growthOnInitial := run.initialValue - plan.initial
+	growthOnContributions := final.PortfolioValue + withdrawals - run.initialValue - contributions
+
+	growthShare := 0.0
+	if total := final.PortfolioValue + withdrawals; total > 0 {
+		growthShare = round1((growthOnInitial + growthOnContributions) / total * 100)
+	}
+
+	explanation := &ResultExplanation{
+		Decomposition: GrowthDecomposition{
+			InitialInvestment:     round2(plan.initial),
+			Contributions:         round2(contributions),
+			GrowthOnInitial:       round2(growthOnInitial),
+			GrowthOnContributions: round2(growthOnContributions),
+			Withdrawals:           round2(withdrawals),
+			GrowthShare:           growthShare,
+		},
+		Yearly: buildYearlyGains(projections, plan.initial),
+	}
+
+	if i := run.crossoverMonth; i >= 0 {
+		explanation.Crossover = &CrossoverPoint{
+			Year:                projections[i].Year,
+			Month:               projections[i].Month,
+			MonthsFromNow:       i + 1,
+			MonthlyGrowth:       round2(run.crossoverGrowth),
+			MonthlyContribution: projections[i].MonthlyContribution,
+		}
+	}
+
+	return explanation
+}
+
+// buildYearlyGains builds the per-year table of contributions versus gains.
+func buildYearlyGains(projections []MonthProjection, initial float64) []YearlyGains {
+	periods := aggregateProjections(projections, initial, granularityYearly)
+
+	yearly := make([]YearlyGains, 0, len(periods))
+	var cumulativeGains float64
+	for _, p := range periods {
+		cumulativeGains += *p.Growth
+		yearly = append(yearly, YearlyGains{
+			Year:                  p.Year,
+			Contributions:         *p.Contributions,
+			Gains:                 *p.Growth,
+			EndValue:              *p.EndValue,
+			CumulativeContributed: *p.TotalContributed,
+			CumulativeGains:       round2(cumulativeGains),
+		})
+	}
+
+	return yearly
+}
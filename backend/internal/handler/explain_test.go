@@ -0,0 +1,83 @@
+package handler
+
+import (
+	"math"
+	"testing"
+)
+
+// TestExplainResultDecomposition tests that the decomposition adds up to the final value,
+// including when purchase fees and withdrawals are paid.
+func TestExplainResultDecomposition(t *testing.T) {
+	retirementAge := 60
+	tests := []struct {
+		name string
+		plan *simulationPlan
+	}{
+		{
+			name: "growth only",
+			plan: &simulationPlan{initial: 20000, monthlyBase: 500, annualRate: 6, startYear: 2025, startMonth: 12, totalMonths: 120},
+		},
+		{
+			name: "fees and withdrawals",
+			plan: &simulationPlan{
+				initial: 20000, monthlyBase: 500, annualRate: 6, startYear: 2025, startMonth: 12, totalMonths: 120,
+				birthYear: 1970, birthMonth: 1, retirementAge: &retirementAge,
+				decumulation: &decumulationConfig{spending: 300},
+				commission:   &CommissionSchedule{Flat: 2}, tradeWeights: []float64{1}, investEvery: 1,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			projections := simulateMonthly(tt.plan, scenarioMedian)
+			final := projections[len(projections)-1]
+			d := explainResult(tt.plan, projections).Decomposition
+
+			sum := d.InitialInvestment + d.Contributions + d.GrowthOnInitial + d.GrowthOnContributions - d.Withdrawals
+			if math.Abs(sum-final.PortfolioValue) > 0.05 {
+				t.Errorf("expected the decomposition to add up to %.2f, got %.2f (%+v)", final.PortfolioValue, sum, d)
+			}
+			if d.Contributions != round2(final.TotalContributed-tt.plan.initial) {
+				t.Errorf("expected contributions of %.2f, got %.2f", final.TotalContributed-tt.plan.initial, d.Contributions)
+			}
+
+			if tt.plan.commission == nil {
+				// The initial investment compounds at 6% for 10 years on its own
+				want := 20000 * (math.Pow(1.06, 10) - 1)
+				if math.Abs(d.GrowthOnInitial-want) > 0.01 {
+					t.Errorf("expected growth on initial of %.2f, got %.2f", want, d.GrowthOnInitial)
+				}
+				return
+			}
+			if *final.FeesPaid == 0 || d.Withdrawals == 0 || d.Withdrawals != *final.TotalWithdrawn {
+				t.Errorf("expected fees and withdrawals, got fees %.2f and withdrawals %.2f", *final.FeesPaid, d.Withdrawals)
+			}
+		})
+	}
+}
+
+// TestExplainResultCrossover tests that growth overtakes a fixed contribution once the
+// contributions have doubled, and that short plans have no crossover.
+func TestExplainResultCrossover(t *testing.T) {
+	plan := &simulationPlan{monthlyBase: 1000, annualRate: 7, startYear: 2025, startMonth: 12, totalMonths: 240}
+	explanation := explainResult(plan, simulateMonthly(plan, scenarioMedian))
+
+	// Growth in month i is 1000 * (1.07^(i/12) - 1), which first exceeds 1000 at i = 123
+	want := CrossoverPoint{Year: 2036, Month: 4, MonthsFromNow: 124, MonthlyContribution: 1000}
+	got := explanation.Crossover
+	if got == nil {
+		t.Fatal("expected a crossover")
+	}
+	if got.Year != want.Year || got.Month != want.Month || got.MonthsFromNow != want.MonthsFromNow || got.MonthlyContribution != want.MonthlyContribution {
+		t.Errorf("expected crossover %+v, got %+v", want, *got)
+	}
+	if got.MonthlyGrowth <= 1000 || got.MonthlyGrowth > 1010 {
+		t.Errorf("expected growth just above 1000, got %.2f", got.MonthlyGrowth)
+	}
+
+	plan.totalMonths = 12
+	if explanation := explainResult(plan, simulateMonthly(plan, scenarioMedian)); explanation.Crossover != nil {
+		t.Errorf("expected no crossover within a year, got %+v", *explanation.Crossover)
+	}
+}
@@ -0,0 +1,330 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/abdonasmane/etfs-simulator/backend/sdk/errors"
+)
+
+// maxFeeOptions is the maximum number of options in a fee impact comparison.
+const maxFeeOptions = 10
+
+// --- Request Types ---
+
+// FeeImpactRequest is the input for comparing the same plan under different fund fees.
+// Returns of an indexSymbol or portfolio come from ETF prices that are already net of those
+// ETFs' own expense ratios, so the ratios are added back before each option's is deducted.
+// A fixed annualReturnRate is treated as the return before fund fees.
+type FeeImpactRequest struct {
+	SimulationInputs
+
+	// Years is the number of years to simulate (1-50).
+	Years int `json:"years" example:"30"`
+
+	// Options are the ETFs or expense ratios compared. The first option is the reference
+	// the others are compared with, e.g. the ETF currently held.
+	Options []FeeOption `json:"options"`
+}
+
+// FeeOption is an ETF or expense ratio to compare.
+type FeeOption struct {
+	// Symbol looks up the ETF's expense ratio (e.g., "SPY", "VOO", "IVV").
+	Symbol *string `json:"symbol,omitempty" example:"VOO"`
+
+	// Name labels the option (default: the symbol).
+	Name string `json:"name,omitempty" example:"Vanguard S&P 500"`
+
+	// ExpenseRatio is the annual fund fee percentage. Required without a known Symbol, overrides it otherwise.
+	ExpenseRatio *float64 `json:"expenseRatio,omitempty" example:"0.03"`
+
+	// SwitchingCost is a one-off cost paid out of the initial investment, e.g. the commissions
+	// and taxes of moving to this ETF (default: 0).
+	SwitchingCost *float64 `json:"switchingCost,omitempty" example:"150"`
+}
+
+// --- Response Types ---
+
+// FeeImpactResponse is the output for fee impact comparison.
+type FeeImpactResponse struct {
+	Inputs FeeImpactRequest `json:"inputs"`
+
+	// GrossFinalValue is the final (median) value without fund fees.
+	GrossFinalValue float64 `json:"grossFinalValue" example:"612400.50"`
+
+	// HeldExpenseRatio is the weighted expense ratio of the ETFs the plan's returns come from,
+	// added back to get the gross returns (0 for a fixed annualReturnRate, cash and unknown ETFs).
+	HeldExpenseRatio float64 `json:"heldExpenseRatio" example:"0.0945"`
+
+	Options []FeeImpactResult `json:"options"`
+}
+
+// FeeImpactResult is the outcome of the plan under one option.
+type FeeImpactResult struct {
+	Name         string  `json:"name" example:"VOO"`
+	ExpenseRatio float64 `json:"expenseRatio" example:"0.03"`
+	FinalValue   float64 `json:"finalValue" example:"607010.25"`
+
+	// FeeDrag is the value lost to fund fees and the switching cost, growth on them included,
+	// and FeeDragPercent its percentage of GrossFinalValue.
+	FeeDrag        float64 `json:"feeDrag" example:"5390.25"`
+	FeeDragPercent float64 `json:"feeDragPercent" example:"0.9"`
+
+	// DifferenceFromReference is the final value minus the reference option's.
+	DifferenceFromReference float64 `json:"differenceFromReference" example:"3720.40"`
+
+	// BreakEven is the first month this option's value exceeds the reference option's
+	// (omitted for the reference and when it never does).
+	BreakEven *MilestoneDate `json:"breakEven,omitempty"`
+
+	// Yearly is the fee drag curve at the end of each calendar year and at the horizon.
+	Yearly []FeeDragPoint `json:"yearly"`
+}
+
+// FeeDragPoint is the cumulative fee drag at a point in time.
+type FeeDragPoint struct {
+	Year    int     `json:"year" example:"2030"`
+	Month   int     `json:"month" example:"12"`
+	Value   float64 `json:"value" example:"48210.30"`
+	FeeDrag float64 `json:"feeDrag" example:"112.45"`
+}
+
+// --- Handler ---
+
+// handleFeeImpact compares a plan under different fund fees.
+//
+//	@Summary		Fee impact comparison
+//	@Description	Runs the same plan under several ETFs or expense ratios and reports the final value, the cumulative fee drag curve and the break-even horizon against the first option
+//	@Tags			simulation
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		FeeImpactRequest	true	"Plan and fee options"
+//	@Param			If-None-Match	header		string	false	"ETag of a previous identical request"
+//	@Success		200		{object}	FeeImpactResponse
+//	@Header			200		{string}	ETag	"Validator for If-None-Match"
+//	@Success		304		"Not Modified"
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		500		{object}	ErrorResponse
+//	@Router			/api/v1/simulate/fees [post]
+func (h *Handler) handleFeeImpact(w http.ResponseWriter, r *http.Request) {
+	var req FeeImpactRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); errors.Check(err) {
+		respondError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	respondCached(h, w, r, cacheEndpointFees, &req, h.prepareFeeImpact, func(resp *FeeImpactResponse) {
+		slog.Debug("fee impact completed",
+			slog.Int("years", resp.Inputs.Years),
+			slog.Int("options", len(resp.Options)),
+			slog.Float64("gross_final_value", resp.GrossFinalValue),
+		)
+	})
+}
+
+// --- Analysis ---
+
+// prepareFeeImpact validates a fee impact request and builds its plan. Its run simulates the
+// plan under each option, reporting progress per option, and stops if ctx is cancelled.
+func (h *Handler) prepareFeeImpact(req *FeeImpactRequest, now time.Time) (func(context.Context) (*FeeImpactResponse, error), error) {
+	if req.Years < 1 || req.Years > 50 {
+		return nil, errors.New("years must be between 1 and 50")
+	}
+	if len(req.Options) < 2 || len(req.Options) > maxFeeOptions {
+		return nil, errors.Errorf("options must contain between 2 and %d options", maxFeeOptions)
+	}
+
+	for i := range req.Options {
+		if err := h.resolveFeeOption(&req.Options[i], req.InitialInvestment); errors.Check(err) {
+			return nil, err
+		}
+	}
+
+	startYear := now.Year()
+	startMonth := int(now.Month())
+	totalMonths := req.Years * 12
+
+	plan, err := h.newPlan(&req.SimulationInputs, startYear, startMonth, totalMonths, startYear+req.Years, startMonth)
+	if errors.Check(err) {
+		return nil, err
+	}
+	ratios, held := h.heldExpenseRatios(plan)
+
+	return func(ctx context.Context) (*FeeImpactResponse, error) {
+		path := withoutExpenseRatios(plan.scenarioPath(scenarioMedian), ratios, held)
+		gross := runEngine(plan, path, false).values
+
+		runs := make([][]float64, len(req.Options))
+		for i, o := range req.Options {
+			if err := ctx.Err(); errors.Check(err) {
+				return nil, err
+			}
+
+			variant := *plan
+			variant.initial -= *o.SwitchingCost
+			runs[i] = runEngine(&variant, withExpenseRatio(path, *o.ExpenseRatio), false).values
+			reportProgress(ctx, i+1, len(req.Options))
+		}
+
+		final := totalMonths - 1
+		resp := &FeeImpactResponse{
+			Inputs:           *req,
+			GrossFinalValue:  round2(gross[final]),
+			HeldExpenseRatio: math.Round(held*1e4) / 1e4,
+			Options:          make([]FeeImpactResult, len(req.Options)),
+		}
+		for i, o := range req.Options {
+			values := runs[i]
+			result := FeeImpactResult{
+				Name:                    o.Name,
+				ExpenseRatio:            *o.ExpenseRatio,
+				FinalValue:              round2(values[final]),
+				FeeDrag:                 round2(gross[final] - values[final]),
+				DifferenceFromReference: round2(values[final] - runs[0][final]),
+				Yearly:                  feeDragCurve(plan, gross, values),
+			}
+			if gross[final] > 0 {
+				result.FeeDragPercent = round1((gross[final] - values[final]) / gross[final] * 100)
+			}
+			if i > 0 {
+				result.BreakEven = breakEven(plan, values, runs[0])
+			}
+			resp.Options[i] = result
+		}
+
+		return resp, nil
+	}, nil
+}
+
+// resolveFeeOption validates an option and fills in its expense ratio, name and switching cost.
+func (h *Handler) resolveFeeOption(o *FeeOption, initial float64) error {
+	if o.Symbol != nil && *o.Symbol != "" {
+		if o.Name == "" {
+			o.Name = *o.Symbol
+		}
+		if o.ExpenseRatio == nil {
+			ratio, ok := h.indexService.GetExpenseRatio(*o.Symbol)
+			if !ok {
+				return errors.New("unknown expense ratio for symbol: " + *o.Symbol)
+			}
+			o.ExpenseRatio = &ratio
+		}
+	}
+
+	if o.ExpenseRatio == nil {
+		return errors.New("each option requires a symbol or an expenseRatio")
+	}
+	if *o.ExpenseRatio < 0 || *o.ExpenseRatio > 5 {
+		return errors.New("expenseRatio must be between 0 and 5")
+	}
+	if o.Name == "" {
+		o.Name = fmt.Sprintf("%g%%", *o.ExpenseRatio)
+	}
+
+	cost := applyDefault(o.SwitchingCost, 0.0)
+	if cost < 0 || cost > initial {
+		return errors.New("switchingCost must be between 0 and initialInvestment")
+	}
+	o.SwitchingCost = &cost
+
+	return nil
+}
+
+// heldExpenseRatios returns the expense ratio of each allocation backing the plan's returns,
+// zero for cash and unknown symbols, and their weighted average.
+func (h *Handler) heldExpenseRatios(plan *simulationPlan) ([]float64, float64) {
+	ratios := make([]float64, len(plan.allocations))
+	var held float64
+	for i, a := range plan.allocations {
+		if ratio, ok := h.indexService.GetExpenseRatio(a.Symbol); ok {
+			ratios[i] = ratio
+			held += ratio * a.Weight / 100
+		}
+	}
+	return ratios, held
+}
+
+// withoutExpenseRatios returns a copy of a return path with the held ETFs' annual fees added
+// back: each symbol's own ratio to its returns and their weighted average to the portfolio's.
+func withoutExpenseRatios(path returnPath, ratios []float64, held float64) returnPath {
+	if held == 0 {
+		return path
+	}
+
+	gross := returnPath{portfolio: make([]float64, len(path.portfolio))}
+	for i, r := range path.portfolio {
+		gross.portfolio[i] = (1+r)/(1-held/100/12) - 1
+	}
+
+	if path.symbols != nil {
+		gross.symbols = make([][]float64, len(path.symbols))
+		for i, month := range path.symbols {
+			gross.symbols[i] = make([]float64, len(month))
+			for j, r := range month {
+				gross.symbols[i][j] = (1+r)/(1-ratios[j]/100/12) - 1
+			}
+		}
+	}
+
+	return gross
+}
+
+// withExpenseRatio returns a copy of a return path with an annual fund fee deducted monthly.
+func withExpenseRatio(path returnPath, expenseRatio float64) returnPath {
+	keep := 1 - expenseRatio/100/12
+
+	net := returnPath{portfolio: make([]float64, len(path.portfolio))}
+	for i, r := range path.portfolio {
+		net.portfolio[i] = (1+r)*keep - 1
+	}
+
+	if path.symbols != nil {
+		net.symbols = make([][]float64, len(path.symbols))
+		for i, month := range path.symbols {
+			net.symbols[i] = make([]float64, len(month))
+			for j, r := range month {
+				net.symbols[i][j] = (1+r)*keep - 1
+			}
+		}
+	}
+
+	return net
+}
+
+// feeDragCurve samples the fee drag at the end of each calendar year and at the horizon.
+func feeDragCurve(plan *simulationPlan, gross, values []float64) []FeeDragPoint {
+	points := []FeeDragPoint{}
+
+	year, month := plan.startYear, plan.startMonth
+	for i := range values {
+		year, month = nextMonth(year, month)
+		if month != 12 && i != len(values)-1 {
+			continue
+		}
+		points = append(points, FeeDragPoint{
+			Year:    year,
+			Month:   month,
+			Value:   round2(values[i]),
+			FeeDrag: round2(gross[i] - values[i]),
+		})
+	}
+
+	return points
+}
+
+// breakEven returns the first month values exceeds reference, or nil if it never does.
+func breakEven(plan *simulationPlan, values, reference []float64) *MilestoneDate {
+	year, month := plan.startYear, plan.startMonth
+	for i := range values {
+		year, month = nextMonth(year, month)
+		if values[i] > reference[i] {
+			return &MilestoneDate{Year: year, Month: month, MonthsFromNow: i + 1, Age: plan.agePtr(year, month)}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+package handler
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"testing"
+
+	"github.com/abdonasmane/etfs-simulator/backend/sdk/errors"
+)
+
+// TestWithExpenseRatio tests that the fund fee is deducted from every monthly return.
+func TestWithExpenseRatio(t *testing.T) {
+	path := returnPath{portfolio: []float64{0.01, 0}, symbols: [][]float64{{0.01}, {0}}}
+
+	net := withExpenseRatio(path, 1.2)
+
+	if math.Abs(net.portfolio[0]-(1.01*0.999-1)) > 1e-12 || math.Abs(net.portfolio[1]+0.001) > 1e-12 {
+		t.Errorf("unexpected net returns: %v", net.portfolio)
+	}
+	if net.symbols[1][0] != net.portfolio[1] {
+		t.Errorf("expected symbol returns to pay the same fee, got %v", net.symbols)
+	}
+	if path.portfolio[0] != 0.01 {
+		t.Error("expected the original path to be unchanged")
+	}
+}
+
+// TestWithoutExpenseRatios tests that held ETFs' fees are added back to their own returns
+// and their weighted average to the portfolio's.
+func TestWithoutExpenseRatios(t *testing.T) {
+	path := returnPath{portfolio: []float64{0.01}, symbols: [][]float64{{0.01, 0.01}}}
+
+	gross := withoutExpenseRatios(path, []float64{1.2, 0}, 0.6)
+
+	if math.Abs(gross.portfolio[0]-(1.01/0.9995-1)) > 1e-12 {
+		t.Errorf("expected the weighted fee to be added back, got %v", gross.portfolio)
+	}
+	if math.Abs(gross.symbols[0][0]-(1.01/0.999-1)) > 1e-12 || math.Abs(gross.symbols[0][1]-0.01) > 1e-12 {
+		t.Errorf("expected each symbol's own fee to be added back, got %v", gross.symbols)
+	}
+	if net := withExpenseRatio(gross, 0.6); math.Abs(net.portfolio[0]-0.01) > 1e-12 {
+		t.Errorf("expected deducting the held fee again to give the original return, got %v", net.portfolio)
+	}
+	if path.portfolio[0] != 0.01 {
+		t.Error("expected the original path to be unchanged")
+	}
+}
+
+// TestHandleFeeImpactHeldExpenseRatio tests that the option matching the held ETF reproduces
+// the plan's own projection, and that fixed rates are treated as gross returns.
+func TestHandleFeeImpactHeldExpenseRatio(t *testing.T) {
+	h := newTestHandler()
+
+	tests := []struct {
+		name   string
+		inputs string
+		held   float64
+	}{
+		{"index", `"indexSymbol":"SPY"`, 0.0945},
+		{"fixed rate", `"annualReturnRate":7`, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inputs := `"years":20,"initialInvestment":10000,"monthlyContribution":500,` + tt.inputs
+
+			w := serve(h, "/api/v1/simulate/years", "{"+inputs+"}", nil)
+			if w.Code != http.StatusOK {
+				t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+			}
+			var simulated struct {
+				Summary SimulateSummary `json:"summary"`
+			}
+			if err := json.NewDecoder(w.Body).Decode(&simulated); errors.Check(err) {
+				t.Fatalf("invalid response: %v", err)
+			}
+
+			w = serve(h, "/api/v1/simulate/fees", "{"+inputs+`,"options":[{"symbol":"SPY"},{"expenseRatio":0}]}`, nil)
+			if w.Code != http.StatusOK {
+				t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+			}
+			var resp FeeImpactResponse
+			if err := json.NewDecoder(w.Body).Decode(&resp); errors.Check(err) {
+				t.Fatalf("invalid response: %v", err)
+			}
+
+			if resp.HeldExpenseRatio != tt.held {
+				t.Errorf("expected a held expense ratio of %g, got %g", tt.held, resp.HeldExpenseRatio)
+			}
+			if resp.Options[1].FinalValue != resp.GrossFinalValue {
+				t.Errorf("expected no fee to give the gross value %.2f, got %.2f", resp.GrossFinalValue, resp.Options[1].FinalValue)
+			}
+
+			// Only the index's returns already pay its fee
+			want := simulated.Summary.FinalValue
+			got := resp.Options[0].FinalValue
+			if tt.held == 0 {
+				want, got = simulated.Summary.FinalValue, resp.GrossFinalValue
+			}
+			if math.Abs(got-want) > 0.02 {
+				t.Errorf("expected a final value of %.2f, got %.2f", want, got)
+			}
+		})
+	}
+}
+
+// TestBreakEven tests the first month an option overtakes the reference.
+func TestBreakEven(t *testing.T) {
+	plan := &simulationPlan{startYear: 2025, startMonth: 11}
+
+	date := breakEven(plan, []float64{90, 100, 111}, []float64{100, 105, 110})
+	if date == nil || date.MonthsFromNow != 3 || date.Year != 2026 || date.Month != 2 {
+		t.Errorf("expected a break-even in February 2026, got %+v", date)
+	}
+	if date := breakEven(plan, []float64{90}, []float64{100}); date != nil {
+		t.Errorf("expected no break-even, got %+v", date)
+	}
+}
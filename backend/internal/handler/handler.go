@@ -8,6 +8,8 @@ import (
 
 	httpSwagger "github.com/swaggo/http-swagger/v2"
 
+	"github.com/abdonasmane/etfs-simulator/backend/internal/cache"
+	"github.com/abdonasmane/etfs-simulator/backend/internal/jobs"
 	"github.com/abdonasmane/etfs-simulator/backend/internal/marketdata"
 	"github.com/abdonasmane/etfs-simulator/backend/internal/metrics"
 	"github.com/abdonasmane/etfs-simulator/backend/sdk/errors"
@@ -17,15 +19,21 @@ import (
 type Handler struct {
 	mux          *http.ServeMux
 	indexService *marketdata.IndexService
+	jobs         *jobs.Manager
 	metrics      *metrics.Metrics
+
+	// results caches encoded simulation responses by request key.
+	results *cache.LRU[string, []byte]
 }
 
 // New creates a new Handler with all routes registered.
-func New(indexService *marketdata.IndexService, m *metrics.Metrics) *Handler {
+func New(indexService *marketdata.IndexService, jobManager *jobs.Manager, results *cache.LRU[string, []byte], m *metrics.Metrics) *Handler {
 	h := &Handler{
 		mux:          http.NewServeMux(),
 		indexService: indexService,
+		jobs:         jobManager,
 		metrics:      m,
+		results:      results,
 	}
 
 	h.registerRoutes()
@@ -37,7 +45,8 @@ func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	// Set CORS headers for all requests
 	w.Header().Set("Access-Control-Allow-Origin", "*")
 	w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
-	w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+	w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, If-None-Match")
+	w.Header().Set("Access-Control-Expose-Headers", "ETag, Location")
 
 	// Handle preflight requests
 	if r.Method == http.MethodOptions {
@@ -68,6 +77,24 @@ func (h *Handler) registerRoutes() {
 	// Simulation endpoints
 	h.mux.HandleFunc("POST /api/v1/simulate/years", h.handleSimulateByYears)
 	h.mux.HandleFunc("POST /api/v1/simulate/target", h.handleSimulateByTarget)
+	h.mux.HandleFunc("POST /api/v1/simulate/years/stream", h.handleSimulateByYearsStream)
+	h.mux.HandleFunc("POST /api/v1/simulate/target/stream", h.handleSimulateByTargetStream)
+	h.mux.HandleFunc("POST /api/v1/simulate/household", h.handleSimulateHousehold)
+	h.mux.HandleFunc("POST /api/v1/simulate/delay", h.handleCostOfDelay)
+	h.mux.HandleFunc("POST /api/v1/simulate/fees", h.handleFeeImpact)
+	h.mux.HandleFunc("POST /api/v1/simulate/compare", h.handleSimulateCompare)
+	h.mux.HandleFunc("POST /api/v1/simulate/sensitivity", h.handleSensitivity)
+	h.mux.HandleFunc("POST /api/v1/simulate/batch", h.handleSimulateBatch)
+
+	// Portfolio construction endpoints
+	h.mux.HandleFunc("POST /api/v1/portfolio/optimize", h.handleOptimizePortfolio)
+	h.mux.HandleFunc("POST /api/v1/portfolio/allocate", h.handleAllocatePortfolio)
+
+	// Asynchronous job endpoints
+	h.mux.HandleFunc("POST /api/v1/jobs", h.handleSubmitJob)
+	h.mux.HandleFunc("GET /api/v1/jobs/{id}", h.handleGetJob)
+	h.mux.HandleFunc("GET /api/v1/jobs/{id}/result", h.handleGetJobResult)
+	h.mux.HandleFunc("DELETE /api/v1/jobs/{id}", h.handleCancelJob)
 }
 
 // ErrorResponse is the standard error response.
@@ -0,0 +1,87 @@
+package handler
+
+import (
+	"bytes"
+	"math/rand/v2"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/abdonasmane/etfs-simulator/backend/internal/cache"
+	"github.com/abdonasmane/etfs-simulator/backend/internal/marketdata"
+	"github.com/abdonasmane/etfs-simulator/backend/internal/metrics"
+)
+
+// testHistoryMonths is the length of the synthetic return history of test indexes.
+const testHistoryMonths = 360
+
+// testNoHistorySymbol is a test index with statistics but no return history.
+const testNoHistorySymbol = "NOHIST"
+
+// testMetrics is shared by test handlers, as metrics can only be registered once.
+var testMetrics = metrics.New()
+
+// newTestHandler creates a handler serving synthetic SPY and QQQ data, with a small result
+// cache and no job manager.
+func newTestHandler() *Handler {
+	return New(testIndexService(), nil, cache.NewLRU[string, []byte](8), testMetrics)
+}
+
+// testIndexService creates an index service loaded with reproducible monthly returns.
+func testIndexService() *marketdata.IndexService {
+	s := marketdata.NewIndexService()
+	rng := rand.New(rand.NewPCG(1, 2))
+
+	for _, idx := range []struct {
+		symbol           string
+		median, low, hi  float64
+		mean, volatility float64
+	}{
+		{"SPY", 8.7, 5.5, 12, 0.008, 0.043},
+		{"QQQ", 13.6, 6, 18, 0.011, 0.06},
+	} {
+		returns := make([]marketdata.MonthlyReturn, testHistoryMonths)
+		for i := range returns {
+			returns[i] = marketdata.MonthlyReturn{
+				Date:   time.Date(1995, time.Month(i+1), 1, 0, 0, 0, 0, time.UTC),
+				Return: idx.mean + idx.volatility*rng.NormFloat64(),
+			}
+		}
+		s.Load(&marketdata.IndexInfo{
+			Symbol:            idx.symbol,
+			AssetClass:        marketdata.AssetClassEquity,
+			MedianReturn:      idx.median,
+			PessimisticReturn: idx.low,
+			OptimisticReturn:  idx.hi,
+			LastPrice:         100,
+			Currency:          "USD",
+		}, returns)
+	}
+
+	// An index without return history, whose target probability fails once simulated
+	s.Load(&marketdata.IndexInfo{
+		Symbol:            testNoHistorySymbol,
+		AssetClass:        marketdata.AssetClassEquity,
+		MedianReturn:      7,
+		PessimisticReturn: 4,
+		OptimisticReturn:  10,
+		LastPrice:         100,
+		Currency:          "USD",
+	}, nil)
+
+	return s
+}
+
+// serve sends a POST request with a JSON body and extra headers through the handler and
+// returns the recorded response.
+func serve(h *Handler, path, body string, header http.Header) *httptest.ResponseRecorder {
+	r := httptest.NewRequest("POST", path, bytes.NewBufferString(body))
+	r.Header.Set("Content-Type", "application/json")
+	for key := range header {
+		r.Header.Set(key, header.Get(key))
+	}
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w
+}
@@ -0,0 +1,91 @@
+package handler
+
+import "math"
+
+// SymbolValue is the portion of the portfolio value attributed to one ETF.
+type SymbolValue struct {
+	Symbol string  `json:"symbol" example:"SPY"`
+	Value  float64 `json:"value" example:"2490.15"`
+}
+
+// attributeHoldings splits the median projections between the portfolio's ETFs and
+// returns the breakdown completed with final values.
+//
+// The simulation rebalances to the target weights every month, so each ETF receives
+// its weight of every contribution and generates growth on its weight of the
+// portfolio at its own rate. Any difference between the blended growth and the sum
+// of per-ETF growth is spread by weight, so attributed values always add up to
+// PortfolioValue. Withdrawals are taken from every ETF in proportion to its value.
+func attributeHoldings(plan *simulationPlan, projections []MonthProjection) []PortfolioBreakdown {
+	n := len(plan.allocations)
+	weights := make([]float64, n)
+	monthlyRates := make([]float64, n)
+	for i, a := range plan.allocations {
+		weights[i] = a.Weight / 100
+		monthlyRates[i] = math.Pow(1+plan.portfolio.symbolRates[i].median/100, 1.0/12.0) - 1
+	}
+
+	contributed := make([]float64, n)
+	gains := make([]float64, n)
+	for i := range contributed {
+		contributed[i] = plan.initial * weights[i]
+	}
+
+	prevValue := plan.initial
+	prevContributed := plan.initial
+	var prevWithdrawn float64
+	for m := range projections {
+		p := &projections[m]
+		contribution := p.TotalContributed - prevContributed
+		var withdrawal float64
+		if p.TotalWithdrawn != nil {
+			withdrawal = *p.TotalWithdrawn - prevWithdrawn
+			prevWithdrawn = *p.TotalWithdrawn
+		}
+		growth := p.PortfolioValue - prevValue - contribution + withdrawal
+
+		// Growth each ETF generated at its own rate, then spread the remainder by weight
+		symbolGrowth := make([]float64, n)
+		var attributed float64
+		for i := range symbolGrowth {
+			symbolGrowth[i] = prevValue * weights[i] * monthlyRates[i]
+			attributed += symbolGrowth[i]
+		}
+
+		// Share of the value before the withdrawal that each ETF gives up
+		withdrawn := 0.0
+		if before := p.PortfolioValue + withdrawal; before > 0 {
+			withdrawn = withdrawal / before
+		}
+
+		p.Holdings = make([]SymbolValue, n)
+		for i, a := range plan.allocations {
+			contributed[i] += contribution * weights[i]
+			gains[i] += symbolGrowth[i] + (growth-attributed)*weights[i]
+			contributed[i] *= 1 - withdrawn
+			gains[i] *= 1 - withdrawn
+			p.Holdings[i] = SymbolValue{
+				Symbol: a.Symbol,
+				Value:  round2(contributed[i] + gains[i]),
+			}
+		}
+
+		prevValue = p.PortfolioValue
+		prevContributed = p.TotalContributed
+	}
+
+	finalValue := projections[len(projections)-1].PortfolioValue
+	breakdown := make([]PortfolioBreakdown, n)
+	copy(breakdown, plan.portfolio.breakdown)
+	for i := range breakdown {
+		value := contributed[i] + gains[i]
+		breakdown[i].FinalValue = round2(value)
+		breakdown[i].TotalContributed = round2(contributed[i])
+		breakdown[i].TotalGain = round2(gains[i])
+		if finalValue > 0 {
+			breakdown[i].ValueShare = round1(value / finalValue * 100)
+		}
+	}
+
+	return breakdown
+}
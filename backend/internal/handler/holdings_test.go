@@ -0,0 +1,100 @@
+package handler
+
+import (
+	"math"
+	"testing"
+)
+
+// testPortfolioPlan builds a plan holding 60% of an ETF returning 10% a year and 40% of one returning 2%.
+func testPortfolioPlan(initial, monthly float64) *simulationPlan {
+	portfolio := &portfolioResult{
+		rates: indexReturnRates{median: 6.8, pessimistic: 6.8, optimistic: 6.8},
+		breakdown: []PortfolioBreakdown{
+			{Symbol: "AAA", Weight: 60, MedianReturn: 10},
+			{Symbol: "BBB", Weight: 40, MedianReturn: 2},
+		},
+		symbolRates: []indexReturnRates{
+			{median: 10, pessimistic: 10, optimistic: 10},
+			{median: 2, pessimistic: 2, optimistic: 2},
+		},
+	}
+
+	return &simulationPlan{
+		initial:     initial,
+		monthlyBase: monthly,
+		startYear:   2025,
+		startMonth:  12,
+		totalMonths: 120,
+		rates:       &portfolio.rates,
+		portfolio:   portfolio,
+		allocations: []PortfolioAllocation{{Symbol: "AAA", Weight: 60}, {Symbol: "BBB", Weight: 40}},
+	}
+}
+
+// TestAttributeHoldings tests that holdings add up to the portfolio value every month and
+// that each ETF's value drifts from its weight by the difference between their returns.
+func TestAttributeHoldings(t *testing.T) {
+	plan := testPortfolioPlan(10000, 500)
+	projections := simulateMonthly(plan, scenarioMedian)
+	breakdown := attributeHoldings(plan, projections)
+
+	rateAAA := annualToMonthly(10)
+	rateBBB := annualToMonthly(2)
+	prevValue := plan.initial
+	prevDrift := 0.0
+
+	for m, p := range projections {
+		if len(p.Holdings) != 2 || p.Holdings[0].Symbol != "AAA" || p.Holdings[1].Symbol != "BBB" {
+			t.Fatalf("month %d: expected AAA and BBB holdings, got %+v", m, p.Holdings)
+		}
+
+		sum := p.Holdings[0].Value + p.Holdings[1].Value
+		if math.Abs(sum-p.PortfolioValue) > 0.02 {
+			t.Errorf("month %d: expected holdings to add up to %.2f, got %.2f", m, p.PortfolioValue, sum)
+		}
+
+		// Per unit of weight, both ETFs receive the same contributions and unattributed growth,
+		// so they only drift apart by the growth of the previous value at their own rates
+		drift := p.Holdings[0].Value/0.6 - p.Holdings[1].Value/0.4
+		if want := prevValue * (rateAAA - rateBBB); math.Abs(drift-prevDrift-want) > 0.05 {
+			t.Errorf("month %d: expected the drift to grow by %.2f, got %.2f", m, want, drift-prevDrift)
+		}
+		prevValue = p.PortfolioValue
+		prevDrift = drift
+	}
+
+	final := projections[len(projections)-1]
+	if math.Abs(breakdown[0].FinalValue+breakdown[1].FinalValue-final.PortfolioValue) > 0.02 {
+		t.Errorf("expected final values to add up to %.2f, got %+v", final.PortfolioValue, breakdown)
+	}
+	if breakdown[0].TotalContributed != round2(final.TotalContributed*0.6) {
+		t.Errorf("expected AAA to receive 60%% of contributions, got %.2f of %.2f", breakdown[0].TotalContributed, final.TotalContributed)
+	}
+	if breakdown[0].ValueShare <= 60 || breakdown[1].ValueShare >= 40 {
+		t.Errorf("expected the faster ETF to outgrow its weight, got shares %.1f and %.1f", breakdown[0].ValueShare, breakdown[1].ValueShare)
+	}
+}
+
+// TestShareModeHoldingsDrift tests that without contributions each ETF held as shares
+// grows at its own rate.
+func TestShareModeHoldingsDrift(t *testing.T) {
+	plan := testPortfolioPlan(10000, 0)
+	plan.shares = &shareConfig{
+		symbols: []string{"AAA", "BBB"},
+		weights: []float64{0.6, 0.4},
+		prices:  []float64{10, 10},
+	}
+
+	projections := simulateMonthly(plan, scenarioMedian)
+	for _, m := range []int{0, 59, 119} {
+		p := projections[m]
+		wantAAA := 6000 * math.Pow(1.10, float64(m+1)/12)
+		wantBBB := 4000 * math.Pow(1.02, float64(m+1)/12)
+		if math.Abs(p.Holdings[0].Value-wantAAA) > 0.01 || math.Abs(p.Holdings[1].Value-wantBBB) > 0.01 {
+			t.Errorf("month %d: expected holdings of %.2f and %.2f, got %+v", m, wantAAA, wantBBB, p.Holdings)
+		}
+		if sum := p.Holdings[0].Value + p.Holdings[1].Value; math.Abs(sum-p.PortfolioValue) > 0.02 {
+			t.Errorf("month %d: expected holdings to add up to %.2f, got %.2f", m, p.PortfolioValue, sum)
+		}
+	}
+}
@@ -0,0 +1,429 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/abdonasmane/etfs-simulator/backend/sdk/errors"
+)
+
+const (
+	// maxHouseholdMembers is the maximum number of members in a household plan.
+	maxHouseholdMembers = 6
+
+	// maxHouseholdGoals is the maximum number of goals in a household plan.
+	maxHouseholdGoals = 20
+)
+
+// householdScenarios are the scenarios every household plan is run through, with their names.
+var householdScenarios = []struct {
+	scenario scenario
+	name     string
+}{
+	{scenarioMedian, "median"},
+	{scenarioPessimistic, "pessimistic"},
+	{scenarioOptimistic, "optimistic"},
+}
+
+// --- Request Types ---
+
+// HouseholdRequest is the input for simulating a household with several members and goals.
+type HouseholdRequest struct {
+	// Years is the number of years to simulate (1-50).
+	Years int `json:"years" example:"30"`
+
+	Members []HouseholdMember `json:"members"`
+	Goals   []HouseholdGoal   `json:"goals,omitempty"`
+}
+
+// HouseholdMember is one contributor of a household with their own portfolio.
+// Plan inputs (return source, contributions, salary, accounts, age, retirement) apply as in
+// single simulations; reporting options (granularity, milestones, targetAmount) are ignored.
+type HouseholdMember struct {
+	Name string `json:"name" example:"Alex"`
+
+	SimulationInputs
+}
+
+// HouseholdGoal is a dated expense paid out of the members' portfolios.
+type HouseholdGoal struct {
+	Name string `json:"name" example:"House deposit"`
+
+	// Amount is the cost at the goal date.
+	Amount float64 `json:"amount" example:"60000"`
+
+	// Year and Month (1-12, default: 12) date the goal.
+	Year  int  `json:"year" example:"2030"`
+	Month *int `json:"month,omitempty" example:"6"`
+
+	// FundedBy earmarks the goal to some members' portfolios (default: shared by all members).
+	// The cost is split in proportion to each funding member's portfolio value at the goal date.
+	FundedBy []string `json:"fundedBy,omitempty" example:"Alex"`
+}
+
+// --- Response Types ---
+
+// HouseholdResponse is the output for household simulation.
+type HouseholdResponse struct {
+	Inputs  HouseholdRequest        `json:"inputs"`
+	Members []HouseholdMemberResult `json:"members"`
+	Goals   []GoalResult            `json:"goals"`
+
+	// Yearly is the household value at the end of each calendar year and at the horizon.
+	Yearly  []HouseholdYear  `json:"yearly"`
+	Summary HouseholdSummary `json:"summary"`
+}
+
+// HouseholdMemberResult is a member's final portfolio after goals are paid.
+type HouseholdMemberResult struct {
+	Name             string  `json:"name" example:"Alex"`
+	TotalContributed float64 `json:"totalContributed" example:"185000"`
+	FinalValue       float64 `json:"finalValue" example:"412050.10"`
+	PessimisticValue float64 `json:"pessimisticValue" example:"301220.00"`
+	OptimisticValue  float64 `json:"optimisticValue" example:"560480.00"`
+
+	// GoalsPaid is the median amount this member paid towards goals.
+	GoalsPaid float64 `json:"goalsPaid" example:"30000"`
+}
+
+// GoalResult reports whether a goal is funded in each scenario.
+type GoalResult struct {
+	Name     string   `json:"name" example:"House deposit"`
+	Amount   float64  `json:"amount" example:"60000"`
+	Year     int      `json:"year" example:"2030"`
+	Month    int      `json:"month" example:"6"`
+	FundedBy []string `json:"fundedBy" example:"Alex,Sam"`
+
+	Scenarios []GoalScenario `json:"scenarios"`
+}
+
+// GoalScenario is the funding of a goal in one scenario.
+type GoalScenario struct {
+	Scenario string `json:"scenario" example:"median"`
+
+	// Available is the funding members' combined value at the goal date, before paying it.
+	Available float64 `json:"available" example:"72400.50"`
+
+	// FundedPercent is the percentage of the goal that could be paid (at most 100).
+	FundedPercent float64 `json:"fundedPercent" example:"100"`
+	Funded        bool    `json:"funded" example:"true"`
+}
+
+// HouseholdYear is the household value at the end of a year.
+type HouseholdYear struct {
+	Year             int           `json:"year" example:"2030"`
+	Month            int           `json:"month" example:"12"`
+	Value            float64       `json:"value" example:"152300.40"`
+	PessimisticValue float64       `json:"pessimisticValue" example:"131050.00"`
+	OptimisticValue  float64       `json:"optimisticValue" example:"176410.00"`
+	Members          []MemberValue `json:"members"`
+}
+
+// MemberValue is the portion of the household value held by one member.
+type MemberValue struct {
+	Name  string  `json:"name" example:"Alex"`
+	Value float64 `json:"value" example:"98020.15"`
+}
+
+// HouseholdSummary contains the household totals.
+type HouseholdSummary struct {
+	TargetDate       string  `json:"targetDate" example:"October 2055"`
+	TotalContributed float64 `json:"totalContributed" example:"360000"`
+	FinalValue       float64 `json:"finalValue" example:"812400.30"`
+	PessimisticValue float64 `json:"pessimisticValue" example:"590110.00"`
+	OptimisticValue  float64 `json:"optimisticValue" example:"1120500.00"`
+
+	// GoalsFunded counts the fully funded goals per scenario.
+	GoalsFunded map[string]int `json:"goalsFunded"`
+}
+
+// --- Handler ---
+
+// handleSimulateHousehold runs a household simulation with several members and goals.
+//
+//	@Summary		Simulate a household
+//	@Description	Simulates several members' portfolios together and reports which dated goals are funded in each scenario
+//	@Tags			simulation
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		HouseholdRequest	true	"Household parameters"
+//	@Param			If-None-Match	header		string	false	"ETag of a previous identical request"
+//	@Success		200		{object}	HouseholdResponse
+//	@Header			200		{string}	ETag	"Validator for If-None-Match"
+//	@Success		304		"Not Modified"
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		500		{object}	ErrorResponse
+//	@Router			/api/v1/simulate/household [post]
+func (h *Handler) handleSimulateHousehold(w http.ResponseWriter, r *http.Request) {
+	var req HouseholdRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); errors.Check(err) {
+		respondError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	respondCached(h, w, r, cacheEndpointHousehold, &req, h.prepareHousehold, func(resp *HouseholdResponse) {
+		slog.Debug("household simulation completed",
+			slog.Int("members", len(resp.Members)),
+			slog.Int("goals", len(resp.Goals)),
+			slog.Int("years", resp.Inputs.Years),
+			slog.Float64("final_value", resp.Summary.FinalValue),
+		)
+	})
+}
+
+// --- Simulation ---
+
+// householdGoal is a validated goal with its month index and funding members.
+type householdGoal struct {
+	month   int // index of the month the goal is paid
+	amount  float64
+	funders []int
+}
+
+// prepareHousehold validates a household request and builds every member's plan. Its run
+// simulates every member through each scenario, reporting progress per scenario, and stops
+// if ctx is cancelled.
+func (h *Handler) prepareHousehold(req *HouseholdRequest, now time.Time) (func(context.Context) (*HouseholdResponse, error), error) {
+	if req.Years < 1 || req.Years > 50 {
+		return nil, errors.New("years must be between 1 and 50")
+	}
+	if len(req.Members) == 0 || len(req.Members) > maxHouseholdMembers {
+		return nil, errors.Errorf("members must contain between 1 and %d members", maxHouseholdMembers)
+	}
+	if len(req.Goals) > maxHouseholdGoals {
+		return nil, errors.Errorf("at most %d goals are allowed", maxHouseholdGoals)
+	}
+
+	startYear := now.Year()
+	startMonth := int(now.Month())
+	totalMonths := req.Years * 12
+	endYear := startYear + req.Years
+
+	// Build each member's plan
+	plans := make([]*simulationPlan, len(req.Members))
+	names := make(map[string]int, len(req.Members))
+	for i := range req.Members {
+		m := &req.Members[i]
+		if m.Name == "" {
+			return nil, errors.New("member name is required")
+		}
+		if _, ok := names[m.Name]; ok {
+			return nil, errors.New("duplicate member name: " + m.Name)
+		}
+		names[m.Name] = i
+
+		plan, err := h.newPlan(&m.SimulationInputs, startYear, startMonth, totalMonths, endYear, startMonth)
+		if errors.Check(err) {
+			return nil, errors.Wrap(err, "member "+m.Name)
+		}
+		plans[i] = plan
+	}
+
+	goals, err := resolveGoals(req.Goals, req.Members, names, startYear, startMonth, totalMonths)
+	if errors.Check(err) {
+		return nil, err
+	}
+
+	return func(ctx context.Context) (*HouseholdResponse, error) {
+		resp := &HouseholdResponse{
+			Inputs:  *req,
+			Members: make([]HouseholdMemberResult, len(plans)),
+			Goals:   make([]GoalResult, len(req.Goals)),
+			Summary: HouseholdSummary{
+				TargetDate:  time.Date(endYear, time.Month(startMonth), 1, 0, 0, 0, 0, time.UTC).Format("January 2006"),
+				GoalsFunded: map[string]int{},
+			},
+		}
+		for i, g := range req.Goals {
+			resp.Goals[i] = GoalResult{
+				Name:     g.Name,
+				Amount:   g.Amount,
+				Year:     g.Year,
+				Month:    *g.Month,
+				FundedBy: g.FundedBy,
+			}
+		}
+
+		// Run each scenario, paying goals in date order
+		runs := make([][]engineResult, len(householdScenarios))
+		for s, sc := range householdScenarios {
+			if err := ctx.Err(); errors.Check(err) {
+				return nil, err
+			}
+
+			withdrawals, scenarios := fundGoals(plans, goals, sc.scenario)
+			for i, gs := range scenarios {
+				gs.Scenario = sc.name
+				resp.Goals[i].Scenarios = append(resp.Goals[i].Scenarios, gs)
+				if gs.Funded {
+					resp.Summary.GoalsFunded[sc.name]++
+				}
+			}
+
+			runs[s] = make([]engineResult, len(plans))
+			for m, plan := range plans {
+				variant := *plan
+				variant.goalWithdrawals = withdrawals[m]
+				runs[s][m] = runEngine(&variant, variant.scenarioPath(sc.scenario), false)
+
+				if sc.scenario == scenarioMedian {
+					for _, amount := range withdrawals[m] {
+						resp.Members[m].GoalsPaid += amount
+					}
+				}
+			}
+			reportProgress(ctx, s+1, len(householdScenarios))
+		}
+
+		// Member and household totals
+		final := totalMonths - 1
+		for m, member := range req.Members {
+			result := &resp.Members[m]
+			result.Name = member.Name
+			result.TotalContributed = round2(runs[0][m].totalContributed)
+			result.FinalValue = round2(runs[0][m].values[final])
+			result.PessimisticValue = round2(runs[1][m].values[final])
+			result.OptimisticValue = round2(runs[2][m].values[final])
+			result.GoalsPaid = round2(result.GoalsPaid)
+
+			resp.Summary.TotalContributed += result.TotalContributed
+			resp.Summary.FinalValue += runs[0][m].values[final]
+			resp.Summary.PessimisticValue += runs[1][m].values[final]
+			resp.Summary.OptimisticValue += runs[2][m].values[final]
+		}
+		resp.Summary.TotalContributed = round2(resp.Summary.TotalContributed)
+		resp.Summary.FinalValue = round2(resp.Summary.FinalValue)
+		resp.Summary.PessimisticValue = round2(resp.Summary.PessimisticValue)
+		resp.Summary.OptimisticValue = round2(resp.Summary.OptimisticValue)
+
+		resp.Yearly = buildHouseholdYears(req.Members, runs, startYear, startMonth, totalMonths)
+		return resp, nil
+	}, nil
+}
+
+// resolveGoals validates the goals and converts their dates to month indices.
+// Defaults are written back so they are echoed in the response inputs.
+func resolveGoals(goals []HouseholdGoal, members []HouseholdMember, names map[string]int, startYear, startMonth, totalMonths int) ([]householdGoal, error) {
+	resolved := make([]householdGoal, len(goals))
+	for i := range goals {
+		g := &goals[i]
+		if g.Name == "" {
+			return nil, errors.New("goal name is required")
+		}
+		if g.Amount <= 0 {
+			return nil, errors.New("goal amount must be > 0: " + g.Name)
+		}
+
+		month := 12
+		if g.Month != nil {
+			month = *g.Month
+		}
+		g.Month = &month
+		if month < 1 || month > 12 {
+			return nil, errors.New("goal month must be between 1 and 12: " + g.Name)
+		}
+
+		index := (g.Year-startYear)*12 + month - startMonth - 1
+		if index < 0 || index >= totalMonths {
+			return nil, errors.New("goal date must be within the simulation: " + g.Name)
+		}
+
+		if len(g.FundedBy) == 0 {
+			for _, m := range members {
+				g.FundedBy = append(g.FundedBy, m.Name)
+			}
+		}
+		funders := make([]int, 0, len(g.FundedBy))
+		for _, name := range g.FundedBy {
+			m, ok := names[name]
+			if !ok {
+				return nil, errors.New("unknown member in fundedBy: " + name)
+			}
+			if slices.Contains(funders, m) {
+				return nil, errors.New("duplicate member in fundedBy: " + name)
+			}
+			funders = append(funders, m)
+		}
+		slices.Sort(funders)
+
+		resolved[i] = householdGoal{month: index, amount: g.Amount, funders: funders}
+	}
+	return resolved, nil
+}
+
+// fundGoals pays the goals in date order in one scenario. Each goal is split between its
+// funding members in proportion to their value at the goal date, after earlier goals.
+// It returns each member's goal withdrawals and the funding of each goal.
+func fundGoals(plans []*simulationPlan, goals []householdGoal, s scenario) ([]map[int]float64, []GoalScenario) {
+	withdrawals := make([]map[int]float64, len(plans))
+	for m := range withdrawals {
+		withdrawals[m] = map[int]float64{}
+	}
+
+	order := make([]int, len(goals))
+	for i := range order {
+		order[i] = i
+	}
+	slices.SortStableFunc(order, func(a, b int) int { return goals[a].month - goals[b].month })
+
+	results := make([]GoalScenario, len(goals))
+	for _, i := range order {
+		g := goals[i]
+
+		// Value of each funder at the goal date, before paying this goal
+		values := make([]float64, len(g.funders))
+		var available float64
+		for k, m := range g.funders {
+			variant := *plans[m]
+			variant.goalWithdrawals = withdrawals[m]
+			values[k] = runEngine(&variant, variant.scenarioPath(s), false).values[g.month]
+			available += values[k]
+		}
+
+		paid := min(g.amount, available)
+		for k, m := range g.funders {
+			if available > 0 {
+				withdrawals[m][g.month] += paid * values[k] / available
+			}
+		}
+
+		results[i] = GoalScenario{
+			Available:     round2(available),
+			FundedPercent: round1(paid / g.amount * 100),
+			Funded:        available >= g.amount,
+		}
+	}
+
+	return withdrawals, results
+}
+
+// buildHouseholdYears samples the household value at the end of each calendar year and at the horizon.
+func buildHouseholdYears(members []HouseholdMember, runs [][]engineResult, startYear, startMonth, totalMonths int) []HouseholdYear {
+	years := []HouseholdYear{}
+
+	year, month := startYear, startMonth
+	for i := 0; i < totalMonths; i++ {
+		year, month = nextMonth(year, month)
+		if month != 12 && i != totalMonths-1 {
+			continue
+		}
+
+		y := HouseholdYear{Year: year, Month: month, Members: make([]MemberValue, len(members))}
+		for m, member := range members {
+			y.Value += runs[0][m].values[i]
+			y.PessimisticValue += runs[1][m].values[i]
+			y.OptimisticValue += runs[2][m].values[i]
+			y.Members[m] = MemberValue{Name: member.Name, Value: round2(runs[0][m].values[i])}
+		}
+		y.Value = round2(y.Value)
+		y.PessimisticValue = round2(y.PessimisticValue)
+		y.OptimisticValue = round2(y.OptimisticValue)
+		years = append(years, y)
+	}
+
+	return years
+}
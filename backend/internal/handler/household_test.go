@@ -0,0 +1,65 @@
+package handler
+
+import (
+	"math"
+	"slices"
+	"testing"
+
+	"github.com/abdonasmane/etfs-simulator/backend/sdk/errors"
+)
+
+// TestFundGoals tests that shared goals are split by value and earmarked goals only draw on their funders.
+func TestFundGoals(t *testing.T) {
+	plans := []*simulationPlan{
+		{initial: 30000, startYear: 2025, startMonth: 12, totalMonths: 12},
+		{initial: 10000, startYear: 2025, startMonth: 12, totalMonths: 12},
+	}
+	goals := []householdGoal{
+		{month: 5, amount: 20000, funders: []int{0, 1}},
+		{month: 2, amount: 15000, funders: []int{1}},
+	}
+
+	withdrawals, results := fundGoals(plans, goals, scenarioMedian)
+
+	// The earmarked goal is paid first and can only take the second member's 10000
+	if results[1].Funded || results[1].FundedPercent != 66.7 {
+		t.Errorf("expected the earmarked goal to be 66.7%% funded, got %+v", results[1])
+	}
+	if withdrawals[1][2] != 10000 {
+		t.Errorf("expected the second member to pay 10000 in month 2, got %.2f", withdrawals[1][2])
+	}
+
+	// The shared goal then falls entirely on the first member
+	if !results[0].Funded || results[0].Available != 30000 {
+		t.Errorf("expected the shared goal to be funded from 30000, got %+v", results[0])
+	}
+	if math.Abs(withdrawals[0][5]-20000) > 1e-9 || withdrawals[1][5] != 0 {
+		t.Errorf("expected the first member to pay the shared goal, got %.2f and %.2f", withdrawals[0][5], withdrawals[1][5])
+	}
+}
+
+// TestResolveGoals tests that goal dates become month indices and that fundedBy defaults to all members.
+func TestResolveGoals(t *testing.T) {
+	members := []HouseholdMember{{Name: "Alex"}, {Name: "Sam"}}
+	names := map[string]int{"Alex": 0, "Sam": 1}
+
+	goals := []HouseholdGoal{
+		{Name: "Car", Amount: 20000, Year: 2027, FundedBy: []string{"Sam", "Alex"}},
+		{Name: "House", Amount: 60000, Year: 2030},
+	}
+	resolved, err := resolveGoals(goals, members, names, 2025, 12, 120)
+	if errors.Check(err) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resolved[0].month != 23 || !slices.Equal(resolved[0].funders, []int{0, 1}) {
+		t.Errorf("expected month 23 funded by [0 1], got %+v", resolved[0])
+	}
+	if !slices.Equal(goals[1].FundedBy, []string{"Alex", "Sam"}) {
+		t.Errorf("expected fundedBy to default to all members, got %v", goals[1].FundedBy)
+	}
+
+	duplicate := []HouseholdGoal{{Name: "Car", Amount: 20000, Year: 2027, FundedBy: []string{"Alex", "Alex"}}}
+	if _, err := resolveGoals(duplicate, members, names, 2025, 12, 120); !errors.Check(err) {
+		t.Error("expected an error for a duplicate member in fundedBy")
+	}
+}
@@ -0,0 +1,278 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/abdonasmane/etfs-simulator/backend/internal/jobs"
+	"github.com/abdonasmane/etfs-simulator/backend/sdk/errors"
+)
+
+// Job types select the simulation a job runs.
+const (
+	jobTypeYears       = "years"
+	jobTypeTarget      = "target"
+	jobTypeHousehold   = "household"
+	jobTypeDelay       = "delay"
+	jobTypeFees        = "fees"
+	jobTypeCompare     = "compare"
+	jobTypeSensitivity = "sensitivity"
+	jobTypeBatch       = "batch"
+)
+
+// --- Request Types ---
+
+// JobRequest is the input for submitting an asynchronous simulation.
+type JobRequest struct {
+	// Type is the simulation to run: "years", "target", "household", "delay", "fees",
+	// "compare", "sensitivity" or "batch".
+	Type string `json:"type" example:"sensitivity"`
+
+	// Request is the body the matching synchronous endpoint takes (a list of items for "batch").
+	Request json.RawMessage `json:"request" swaggertype:"object"`
+}
+
+// --- Response Types ---
+
+// JobResponse is the status of an asynchronous simulation.
+type JobResponse struct {
+	ID   string `json:"id" example:"3f9a6c0e5b7d41e2a8c4f1d6b9e07a53"`
+	Type string `json:"type" example:"sensitivity"`
+
+	// Status is "queued", "running", "succeeded", "failed" or "cancelled".
+	Status string `json:"status" example:"running"`
+
+	// Progress is the completed fraction of the job, from 0 to 1.
+	Progress float64 `json:"progress" example:"0.4"`
+
+	// Error is the reason a job failed.
+	Error string `json:"error,omitempty" example:"years must be between 1 and 50"`
+
+	CreatedAt  time.Time  `json:"createdAt" example:"2026-10-18T09:30:00Z"`
+	StartedAt  *time.Time `json:"startedAt,omitempty" example:"2026-10-18T09:30:01Z"`
+	FinishedAt *time.Time `json:"finishedAt,omitempty" example:"2026-10-18T09:30:42Z"`
+
+	// ExpiresAt is when a finished job and its result are discarded.
+	ExpiresAt *time.Time `json:"expiresAt,omitempty" example:"2026-10-18T09:45:42Z"`
+
+	// ResultURL is where the result is retrieved (only present once the job has succeeded).
+	ResultURL string `json:"resultUrl,omitempty" example:"/api/v1/jobs/3f9a6c0e5b7d41e2a8c4f1d6b9e07a53/result"`
+}
+
+// --- Handlers ---
+
+// handleSubmitJob queues a simulation to run in the background.
+//
+//	@Summary		Submit job
+//	@Description	Queues a simulation that may outlast the request timeout and returns its job ID; poll the job for progress and fetch its result once it has succeeded
+//	@Tags			jobs
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		JobRequest	true	"Job type and simulation request"
+//	@Success		202		{object}	JobResponse
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		503		{object}	ErrorResponse
+//	@Router			/api/v1/jobs [post]
+func (h *Handler) handleSubmitJob(w http.ResponseWriter, r *http.Request) {
+	var req JobRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); errors.Check(err) {
+		respondError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	fn, err := h.jobFunc(req.Type, req.Request)
+	if errors.Check(err) {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	job, err := h.jobs.Submit(req.Type, fn)
+	if errors.Is(err, jobs.ErrQueueFull) || errors.Is(err, jobs.ErrClosed) {
+		respondError(w, http.StatusServiceUnavailable, err.Error())
+		return
+	}
+	if errors.Check(err) {
+		respondError(w, http.StatusInternalServerError, "failed to submit job")
+		return
+	}
+
+	slog.Debug("job submitted",
+		slog.String("id", job.ID),
+		slog.String("type", job.Type),
+	)
+
+	w.Header().Set("Location", "/api/v1/jobs/"+job.ID)
+	respondJSON(w, http.StatusAccepted, newJobResponse(job))
+}
+
+// handleGetJob reports the status and progress of a job.
+//
+//	@Summary		Get job
+//	@Description	Returns the status and progress of a job; finished jobs are kept until their expiry
+//	@Tags			jobs
+//	@Produce		json
+//	@Param			id	path		string	true	"Job ID"
+//	@Success		200	{object}	JobResponse
+//	@Failure		404	{object}	ErrorResponse
+//	@Router			/api/v1/jobs/{id} [get]
+func (h *Handler) handleGetJob(w http.ResponseWriter, r *http.Request) {
+	job, err := h.jobs.Get(r.PathValue("id"))
+	if errors.Check(err) {
+		respondError(w, http.StatusNotFound, err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusOK, newJobResponse(job))
+}
+
+// handleGetJobResult returns the result of a succeeded job.
+//
+//	@Summary		Get job result
+//	@Description	Returns the response of the job's simulation, as the matching synchronous endpoint would
+//	@Tags			jobs
+//	@Produce		json
+//	@Param			id	path		string	true	"Job ID"
+//	@Success		200	{object}	object
+//	@Failure		404	{object}	ErrorResponse
+//	@Failure		409	{object}	ErrorResponse
+//	@Router			/api/v1/jobs/{id}/result [get]
+func (h *Handler) handleGetJobResult(w http.ResponseWriter, r *http.Request) {
+	job, err := h.jobs.Get(r.PathValue("id"))
+	if errors.Check(err) {
+		respondError(w, http.StatusNotFound, err.Error())
+		return
+	}
+
+	switch job.Status {
+	case jobs.StatusSucceeded:
+		respondJSON(w, http.StatusOK, job.Result)
+	case jobs.StatusFailed:
+		respondError(w, http.StatusConflict, "job failed: "+job.Error)
+	default:
+		respondError(w, http.StatusConflict, "job is "+string(job.Status))
+	}
+}
+
+// handleCancelJob cancels a queued or running job.
+//
+//	@Summary		Cancel job
+//	@Description	Cancels a queued or running job; finished jobs cannot be cancelled
+//	@Tags			jobs
+//	@Produce		json
+//	@Param			id	path		string	true	"Job ID"
+//	@Success		200	{object}	JobResponse
+//	@Failure		404	{object}	ErrorResponse
+//	@Failure		409	{object}	ErrorResponse
+//	@Router			/api/v1/jobs/{id} [delete]
+func (h *Handler) handleCancelJob(w http.ResponseWriter, r *http.Request) {
+	job, err := h.jobs.Cancel(r.PathValue("id"))
+	if errors.Is(err, jobs.ErrNotFound) {
+		respondError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	if errors.Check(err) {
+		respondError(w, http.StatusConflict, err.Error())
+		return
+	}
+
+	slog.Debug("job cancelled",
+		slog.String("id", job.ID),
+		slog.String("type", job.Type),
+	)
+
+	respondJSON(w, http.StatusOK, newJobResponse(job))
+}
+
+// --- Jobs ---
+
+// jobFunc decodes a job's request for its type and returns the simulation to run.
+func (h *Handler) jobFunc(typ string, raw json.RawMessage) (jobs.Func, error) {
+	switch typ {
+	case jobTypeYears:
+		return simulationJob(raw, h.prepareYears)
+	case jobTypeTarget:
+		return simulationJob(raw, h.prepareTarget)
+	case jobTypeHousehold:
+		return simulationJob(raw, h.prepareHousehold)
+	case jobTypeDelay:
+		return simulationJob(raw, h.prepareCostOfDelay)
+	case jobTypeFees:
+		return simulationJob(raw, h.prepareFeeImpact)
+	case jobTypeCompare:
+		return simulationJob(raw, h.prepareCompare)
+	case jobTypeSensitivity:
+		return simulationJob(raw, h.prepareSensitivity)
+	case jobTypeBatch:
+		return h.batchJob(raw)
+	default:
+		return nil, errors.New("unknown job type: " + typ)
+	}
+}
+
+// simulationJob decodes and prepares a request, so invalid requests are rejected when
+// submitted, and returns a job running it. The simulation stops when the job is cancelled
+// and reports its progress to the job.
+func simulationJob[Req, Resp any](raw json.RawMessage, prepare prepareFunc[Req, Resp]) (jobs.Func, error) {
+	var req Req
+	if err := json.Unmarshal(raw, &req); errors.Check(err) {
+		return nil, errors.New("invalid request")
+	}
+
+	run, err := prepare(&req, time.Now())
+	if errors.Check(err) {
+		return nil, err
+	}
+
+	return func(ctx context.Context, progress func(float64)) (any, error) {
+		ctx = withProgress(ctx, func(completed, total int) {
+			progress(float64(completed) / float64(total))
+		})
+		return run(ctx)
+	}, nil
+}
+
+// batchJob decodes and prepares batch items and returns a job running them, reporting
+// progress per item. As in a synchronous batch, invalid items fail on their own.
+func (h *Handler) batchJob(raw json.RawMessage) (jobs.Func, error) {
+	var items []BatchItem
+	if err := json.Unmarshal(raw, &items); errors.Check(err) {
+		return nil, errors.New("invalid request")
+	}
+	if len(items) == 0 || len(items) > maxBatchItems {
+		return nil, errors.Errorf("batch must contain between 1 and %d items", maxBatchItems)
+	}
+
+	results := make([]BatchResult, len(items))
+	runs := h.prepareBatch(items, results, time.Now())
+
+	return func(ctx context.Context, progress func(float64)) (any, error) {
+		var finished atomic.Int64
+		h.runBatch(ctx, runs, results, func() {
+			progress(float64(finished.Add(1)) / float64(len(items)))
+		})
+		return newBatchResponse(results), nil
+	}, nil
+}
+
+// newJobResponse converts a job snapshot to its response.
+func newJobResponse(job jobs.Job) JobResponse {
+	resp := JobResponse{
+		ID:         job.ID,
+		Type:       job.Type,
+		Status:     string(job.Status),
+		Progress:   round2(job.Progress),
+		Error:      job.Error,
+		CreatedAt:  job.CreatedAt,
+		StartedAt:  job.StartedAt,
+		FinishedAt: job.FinishedAt,
+		ExpiresAt:  job.ExpiresAt,
+	}
+	if job.Status == jobs.StatusSucceeded {
+		resp.ResultURL = "/api/v1/jobs/" + job.ID + "/result"
+	}
+	return resp
+}
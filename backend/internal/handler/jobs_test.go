@@ -0,0 +1,94 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"slices"
+	"testing"
+
+	"github.com/abdonasmane/etfs-simulator/backend/internal/jobs"
+	"github.com/abdonasmane/etfs-simulator/backend/sdk/errors"
+)
+
+// TestJobFunc tests that job requests are decoded and prepared for their type when submitted.
+func TestJobFunc(t *testing.T) {
+	h := newTestHandler()
+
+	tests := []struct {
+		name    string
+		typ     string
+		request string
+		wantErr bool
+	}{
+		{"years", jobTypeYears, `{"years":10}`, false},
+		{"sensitivity", jobTypeSensitivity, `{"years":10,"indexSymbol":"SPY"}`, false},
+		{"batch", jobTypeBatch, `[{"request":{}}]`, false},
+		{"unknown type", "montecarlo", `{}`, true},
+		{"invalid request", jobTypeCompare, `[]`, true},
+		{"invalid years", jobTypeYears, `{"years":500}`, true},
+		{"unknown symbol", jobTypeTarget, `{"targetYear":2060,"indexSymbol":"XYZ"}`, true},
+		{"empty batch", jobTypeBatch, `[]`, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fn, err := h.jobFunc(tt.typ, json.RawMessage(tt.request))
+			if errors.Check(err) != tt.wantErr {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+			if !tt.wantErr && fn == nil {
+				t.Error("expected a job function")
+			}
+		})
+	}
+}
+
+// TestHandleSubmitJobInvalid tests that invalid simulation requests are rejected before being queued.
+func TestHandleSubmitJobInvalid(t *testing.T) {
+	h := newTestHandler()
+	for _, body := range []string{
+		`{"type":"years","request":{"years":500}}`,
+		`{"type":"fees","request":{"years":10,"options":[{"symbol":"XYZ"},{"expenseRatio":0.1}]}}`,
+	} {
+		if w := serve(h, "/api/v1/jobs", body, nil); w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", body, w.Code)
+		}
+	}
+}
+
+// TestSimulationJob tests that simulation jobs report their progress and stop once cancelled.
+func TestSimulationJob(t *testing.T) {
+	h := &Handler{}
+	fn, err := h.jobFunc(jobTypeDelay, json.RawMessage(`{"years":10,"maxDelayYears":4,"initialInvestment":1000}`))
+	if errors.Check(err) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var reports []float64
+	if _, err := fn(context.Background(), func(p float64) { reports = append(reports, p) }); errors.Check(err) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !slices.Equal(reports, []float64{0.25, 0.5, 0.75, 1}) {
+		t.Errorf("expected progress per delay, got %v", reports)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := fn(ctx, func(float64) {}); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected the cancelled job to stop, got %v", err)
+	}
+}
+
+// TestNewJobResponse tests that the result URL is only set once a job has succeeded.
+func TestNewJobResponse(t *testing.T) {
+	running := newJobResponse(jobs.Job{ID: "abc", Status: jobs.StatusRunning, Progress: 0.123})
+	if running.ResultURL != "" || running.Progress != 0.12 {
+		t.Errorf("unexpected running response: %+v", running)
+	}
+
+	succeeded := newJobResponse(jobs.Job{ID: "abc", Status: jobs.StatusSucceeded, Progress: 1})
+	if succeeded.ResultURL != "/api/v1/jobs/abc/result" {
+		t.Errorf("expected result URL, got %q", succeeded.ResultURL)
+	}
+}
@@ -0,0 +1,109 @@
+package handler
+
+import (
+	"sort"
+
+	"github.com/abdonasmane/etfs-simulator/backend/sdk/errors"
+)
+
+const (
+	// defaultMilestoneIntervalYears is the default spacing of contribution milestones.
+	defaultMilestoneIntervalYears = 5
+
+	// maxValueMilestones caps the number of value milestones per request.
+	maxValueMilestones = 20
+)
+
+// defaultValueMilestones are the round numbers reported when no milestones are requested.
+var defaultValueMilestones = []float64{10_000, 50_000, 100_000, 250_000, 500_000, 1_000_000}
+
+// ValueMilestone shows when the portfolio first reaches a given value on each path.
+type ValueMilestone struct {
+	Amount float64 `json:"amount" example:"100000"`
+
+	// Median is when the median path first reaches the amount. Omitted if never reached.
+	Median *MilestoneDate `json:"median,omitempty"`
+
+	// Range dates (only present when IndexSymbol or Portfolio is provided). Omitted if never reached.
+	Pessimistic *MilestoneDate `json:"pessimistic,omitempty"`
+	Optimistic  *MilestoneDate `json:"optimistic,omitempty"`
+}
+
+// MilestoneDate is the month in which a milestone is first reached.
+type MilestoneDate struct {
+	Year          int `json:"year" example:"2033"`
+	Month         int `json:"month" example:"7"`
+	MonthsFromNow int `json:"monthsFromNow" example:"81"`
+
+	// Age at that month (only present when BirthYear is provided)
+	Age *int `json:"age,omitempty" example:"41"`
+}
+
+// applyMilestones validates the milestone options and stores them on the plan.
+func applyMilestones(plan *simulationPlan, amounts []float64, intervalYears *int) error {
+	plan.milestoneInterval = defaultMilestoneIntervalYears
+	if intervalYears != nil {
+		plan.milestoneInterval = *intervalYears
+	}
+	if plan.milestoneInterval < 1 || plan.milestoneInterval > 25 {
+		return errors.New("milestoneIntervalYears must be between 1 and 25")
+	}
+
+	if len(amounts) == 0 {
+		// Only report default milestones that are still ahead
+		for _, a := range defaultValueMilestones {
+			if a > plan.initial {
+				plan.valueMilestones = append(plan.valueMilestones, a)
+			}
+		}
+		return nil
+	}
+
+	if len(amounts) > maxValueMilestones {
+		return errors.Errorf("valueMilestones cannot have more than %d entries", maxValueMilestones)
+	}
+	for _, a := range amounts {
+		if a <= 0 {
+			return errors.New("valueMilestones must be > 0")
+		}
+	}
+
+	plan.valueMilestones = append([]float64(nil), amounts...)
+	sort.Float64s(plan.valueMilestones)
+	return nil
+}
+
+// buildValueMilestones finds when each milestone is first reached on the median,
+// pessimistic and optimistic paths. A milestone already covered by the initial
+// investment is reached at month 0.
+func buildValueMilestones(plan *simulationPlan, projections []MonthProjection) []ValueMilestone {
+	milestones := make([]ValueMilestone, 0, len(plan.valueMilestones))
+
+	for _, amount := range plan.valueMilestones {
+		m := ValueMilestone{
+			Amount: amount,
+			Median: firstReached(plan, projections, amount, func(p MonthProjection) *float64 { return &p.PortfolioValue }),
+		}
+		if plan.rates != nil {
+			m.Pessimistic = firstReached(plan, projections, amount, func(p MonthProjection) *float64 { return p.PessimisticValue })
+			m.Optimistic = firstReached(plan, projections, amount, func(p MonthProjection) *float64 { return p.OptimisticValue })
+		}
+		milestones = append(milestones, m)
+	}
+
+	return milestones
+}
+
+// firstReached returns the first month in which value(p) reaches amount, or nil if it never does.
+func firstReached(plan *simulationPlan, projections []MonthProjection, amount float64, value func(MonthProjection) *float64) *MilestoneDate {
+	if plan.initial >= amount {
+		return &MilestoneDate{Year: plan.startYear, Month: plan.startMonth, MonthsFromNow: 0, Age: plan.agePtr(plan.startYear, plan.startMonth)}
+	}
+
+	for i, p := range projections {
+		if v := value(p); v != nil && *v >= amount {
+			return &MilestoneDate{Year: p.Year, Month: p.Month, MonthsFromNow: i + 1, Age: p.Age}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package handler
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/abdonasmane/etfs-simulator/backend/sdk/errors"
+)
+
+// TestApplyMilestones tests the default milestones and interval, and the validation of requested ones.
+func TestApplyMilestones(t *testing.T) {
+	plan := &simulationPlan{initial: 20000}
+	if err := applyMilestones(plan, nil, nil); errors.Check(err) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := []float64{50_000, 100_000, 250_000, 500_000, 1_000_000}; !slices.Equal(plan.valueMilestones, want) {
+		t.Errorf("expected the default milestones above the initial investment %v, got %v", want, plan.valueMilestones)
+	}
+	if plan.milestoneInterval != defaultMilestoneIntervalYears {
+		t.Errorf("expected the default interval, got %d", plan.milestoneInterval)
+	}
+
+	plan = &simulationPlan{}
+	interval := 2
+	if err := applyMilestones(plan, []float64{30000, 5000}, &interval); errors.Check(err) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !slices.Equal(plan.valueMilestones, []float64{5000, 30000}) || plan.milestoneInterval != 2 {
+		t.Errorf("expected sorted milestones every 2 years, got %v every %d", plan.valueMilestones, plan.milestoneInterval)
+	}
+
+	invalid := 0
+	if err := applyMilestones(&simulationPlan{}, nil, &invalid); !errors.Check(err) {
+		t.Error("expected an error for a zero interval")
+	}
+	if err := applyMilestones(&simulationPlan{}, []float64{-1}, nil); !errors.Check(err) {
+		t.Error("expected an error for a negative milestone")
+	}
+	if err := applyMilestones(&simulationPlan{}, make([]float64, maxValueMilestones+1), nil); !errors.Check(err) {
+		t.Error("expected an error for too many milestones")
+	}
+}
+
+// TestBuildValueMilestones tests the first month each milestone is reached, including
+// milestones covered by the initial investment and milestones never reached.
+func TestBuildValueMilestones(t *testing.T) {
+	plan := &simulationPlan{
+		initial:         3000,
+		monthlyBase:     1000,
+		startYear:       2025,
+		startMonth:      12,
+		totalMonths:     24,
+		valueMilestones: []float64{2000, 8000, 1_000_000},
+	}
+	milestones := buildValueMilestones(plan, simulateMonthly(plan, scenarioMedian))
+
+	if len(milestones) != 3 {
+		t.Fatalf("expected 3 milestones, got %d", len(milestones))
+	}
+	if got := milestones[0].Median; got == nil || got.MonthsFromNow != 0 || got.Year != 2025 || got.Month != 12 {
+		t.Errorf("expected the initial investment to reach 2000 at the start, got %+v", got)
+	}
+
+	// Without growth the value is 3000 + 1000 per month, so 8000 is reached in the fifth month
+	if got := milestones[1].Median; got == nil || got.MonthsFromNow != 5 || got.Year != 2026 || got.Month != 5 {
+		t.Errorf("expected 8000 to be reached in May 2026, got %+v", got)
+	}
+	if milestones[1].Pessimistic != nil || milestones[1].Optimistic != nil {
+		t.Error("expected no range dates for a fixed-rate plan")
+	}
+
+	if milestones[2].Median != nil {
+		t.Errorf("expected 1000000 never to be reached, got %+v", milestones[2].Median)
+	}
+}
+
+// TestBuildContributionMilestones tests that contribution milestones follow the interval and end at the final year.
+func TestBuildContributionMilestones(t *testing.T) {
+	plan := &simulationPlan{monthlyBase: 100, startYear: 2025, startMonth: 12, totalMonths: 72}
+	projections := simulateMonthly(plan, scenarioMedian)
+
+	tests := []struct {
+		interval int
+		want     []int
+	}{
+		{2, []int{2027, 2029, 2031}},
+		{5, []int{2030, 2031}},
+		{10, []int{2031}},
+	}
+
+	for _, tt := range tests {
+		var years []int
+		for _, m := range buildContributionMilestones(projections, plan.startYear, tt.interval) {
+			years = append(years, m.Year)
+		}
+		if !slices.Equal(years, tt.want) {
+			t.Errorf("interval %d: expected years %v, got %v", tt.interval, tt.want, years)
+		}
+	}
+}
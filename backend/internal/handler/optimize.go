@@ -0,0 +1,307 @@
+package handler
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"math"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/abdonasmane/etfs-simulator/backend/internal/portfolio"
+	"github.com/abdonasmane/etfs-simulator/backend/sdk/errors"
+)
+
+const (
+	// minOptimizeSymbols and maxOptimizeSymbols bound the symbols of an optimization.
+	minOptimizeSymbols = 2
+	maxOptimizeSymbols = 10
+
+	// minOptimizeMonths is the minimum shared history the estimates are based on.
+	minOptimizeMonths = 36
+
+	// defaultFrontierPoints and maxFrontierPoints are the default and maximum number of
+	// efficient frontier points.
+	defaultFrontierPoints = 20
+	maxFrontierPoints     = 50
+)
+
+// --- Request Types ---
+
+// OptimizeRequest is the input for mean-variance portfolio optimization.
+type OptimizeRequest struct {
+	// Symbols are the ETFs to allocate between (2-10). "CASH" adds a cash sleeve earning CashInterestRate.
+	Symbols []string `json:"symbols" example:"SPY,QQQ,EFA,AGG"`
+
+	// CashInterestRate is the annual interest rate of the "CASH" symbol.
+	CashInterestRate *float64 `json:"cashInterestRate,omitempty" example:"3"`
+
+	// Constraints bound the weight of some symbols; the others range from 0 to 100.
+	Constraints []WeightConstraint `json:"constraints,omitempty"`
+
+	// RiskFreeRate is the annual return the Sharpe ratio is measured against (default: 2).
+	RiskFreeRate *float64 `json:"riskFreeRate,omitempty" example:"2"`
+
+	// FrontierPoints is the number of efficient frontier portfolios returned (default: 20, max 50).
+	FrontierPoints *int `json:"frontierPoints,omitempty" example:"20"`
+}
+
+// WeightConstraint bounds the weight percentage of a symbol. Weights are always long-only.
+type WeightConstraint struct {
+	Symbol string   `json:"symbol" example:"QQQ"`
+	Min    *float64 `json:"min,omitempty" example:"5"`
+	Max    *float64 `json:"max,omitempty" example:"40"`
+}
+
+// --- Response Types ---
+
+// OptimizeResponse is the output for portfolio optimization.
+type OptimizeResponse struct {
+	Inputs OptimizeRequest `json:"inputs"`
+
+	// HistoryMonths is the number of months of shared history the estimates are based on.
+	HistoryMonths int `json:"historyMonths" example:"240"`
+
+	Assets []AssetEstimate `json:"assets"`
+
+	MinVariance OptimizedPortfolio `json:"minVariance"`
+	MaxSharpe   OptimizedPortfolio `json:"maxSharpe"`
+
+	// Frontier holds efficient portfolios from the minimum-variance to the maximum-return one.
+	Frontier []OptimizedPortfolio `json:"frontier"`
+}
+
+// AssetEstimate is the historical annual return and volatility of a symbol.
+type AssetEstimate struct {
+	Symbol         string  `json:"symbol" example:"SPY"`
+	ExpectedReturn float64 `json:"expectedReturn" example:"10.4"`
+	Volatility     float64 `json:"volatility" example:"15.2"`
+}
+
+// OptimizedPortfolio is a set of weights with its historical annual return and risk.
+type OptimizedPortfolio struct {
+	// Portfolio can be sent as is as the Portfolio of a simulation request (zero weights are left out).
+	Portfolio []PortfolioAllocation `json:"portfolio"`
+
+	ExpectedReturn float64 `json:"expectedReturn" example:"8.9"`
+	Volatility     float64 `json:"volatility" example:"11.3"`
+	SharpeRatio    float64 `json:"sharpeRatio" example:"0.61"`
+}
+
+// --- Handler ---
+
+// handleOptimizePortfolio computes mean-variance optimal weights for a set of ETFs.
+//
+//	@Summary		Optimize portfolio
+//	@Description	Estimates returns and covariances from the aligned monthly history of the symbols and computes the minimum-variance and maximum-Sharpe portfolios and the efficient frontier under long-only weight constraints
+//	@Tags			portfolio
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		OptimizeRequest	true	"Symbols and constraints"
+//	@Param			If-None-Match	header		string	false	"ETag of a previous identical request"
+//	@Success		200		{object}	OptimizeResponse
+//	@Header			200		{string}	ETag	"Validator for If-None-Match"
+//	@Success		304		"Not Modified"
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		500		{object}	ErrorResponse
+//	@Router			/api/v1/portfolio/optimize [post]
+func (h *Handler) handleOptimizePortfolio(w http.ResponseWriter, r *http.Request) {
+	var req OptimizeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); errors.Check(err) {
+		respondError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	respondCached(h, w, r, cacheEndpointOptimize, &req, h.prepareOptimize, func(resp *OptimizeResponse) {
+		slog.Debug("portfolio optimization completed",
+			slog.Int("symbols", len(resp.Inputs.Symbols)),
+			slog.Int("history_months", resp.HistoryMonths),
+			slog.Float64("max_sharpe", resp.MaxSharpe.SharpeRatio),
+		)
+	})
+}
+
+// --- Optimization ---
+
+// prepareOptimize validates an optimization request and loads its history. Its run computes
+// the optimal portfolios.
+func (h *Handler) prepareOptimize(req *OptimizeRequest, _ time.Time) (func(context.Context) (*OptimizeResponse, error), error) {
+	riskFree := applyDefault(req.RiskFreeRate, 2)
+	if riskFree < -5 || riskFree > 20 {
+		return nil, errors.New("riskFreeRate must be between -5 and 20")
+	}
+	req.RiskFreeRate = &riskFree
+
+	points := defaultFrontierPoints
+	if req.FrontierPoints != nil {
+		points = *req.FrontierPoints
+	}
+	if points < 2 || points > maxFrontierPoints {
+		return nil, errors.Errorf("frontierPoints must be between 2 and %d", maxFrontierPoints)
+	}
+	req.FrontierPoints = &points
+
+	returns, err := h.assetHistory(req.Symbols, req.CashInterestRate)
+	if errors.Check(err) {
+		return nil, err
+	}
+
+	bounds, err := weightBounds(req.Symbols, req.Constraints)
+	if errors.Check(err) {
+		return nil, err
+	}
+
+	return func(context.Context) (*OptimizeResponse, error) {
+		e := portfolio.Estimate(returns)
+		minVariance, err := portfolio.MinVariance(e, bounds)
+		if errors.Check(err) {
+			return nil, err
+		}
+		maxSharpe, err := portfolio.MaxSharpe(e, bounds, riskFree/100)
+		if errors.Check(err) {
+			return nil, err
+		}
+		frontier, err := portfolio.Frontier(e, bounds, points)
+		if errors.Check(err) {
+			return nil, err
+		}
+
+		resp := &OptimizeResponse{
+			Inputs:        *req,
+			HistoryMonths: len(returns),
+			Assets:        make([]AssetEstimate, len(req.Symbols)),
+			MinVariance:   optimizedPortfolio(e, req.Symbols, minVariance, riskFree),
+			MaxSharpe:     optimizedPortfolio(e, req.Symbols, maxSharpe, riskFree),
+			Frontier:      make([]OptimizedPortfolio, len(frontier)),
+		}
+		for i, symbol := range req.Symbols {
+			resp.Assets[i] = AssetEstimate{
+				Symbol:         symbol,
+				ExpectedReturn: round2(e.Mean[i] * 100),
+				Volatility:     round2(math.Sqrt(e.Cov[i][i]) * 100),
+			}
+		}
+		for k, weights := range frontier {
+			resp.Frontier[k] = optimizedPortfolio(e, req.Symbols, weights, riskFree)
+		}
+
+		return resp, nil
+	}, nil
+}
+
+// assetHistory validates the symbols of a portfolio construction and returns their
+// aligned monthly returns ([month][symbol]).
+func (h *Handler) assetHistory(symbols []string, cashRate *float64) ([][]float64, error) {
+	if len(symbols) < minOptimizeSymbols || len(symbols) > maxOptimizeSymbols {
+		return nil, errors.Errorf("symbols must contain between %d and %d symbols", minOptimizeSymbols, maxOptimizeSymbols)
+	}
+	if cashRate != nil && (*cashRate < -5 || *cashRate > 20) {
+		return nil, errors.New("cashInterestRate must be between -5 and 20")
+	}
+
+	seen := make(map[string]bool, len(symbols))
+	allocations := make([]PortfolioAllocation, len(symbols))
+	for i, symbol := range symbols {
+		if seen[symbol] {
+			return nil, errors.New("duplicate symbol: " + symbol)
+		}
+		seen[symbol] = true
+
+		if _, err := h.lookupIndex(symbol, cashRate); errors.Check(err) {
+			return nil, err
+		}
+		allocations[i] = PortfolioAllocation{Symbol: symbol}
+	}
+	if onlyCash(allocations) {
+		return nil, errors.New("symbols must include an ETF")
+	}
+
+	history, err := h.historicalReturns(allocations, cashRate)
+	if errors.Check(err) {
+		return nil, err
+	}
+	if len(history.symbols) < minOptimizeMonths {
+		return nil, errors.Errorf("symbols share less than %d months of history", minOptimizeMonths)
+	}
+
+	return history.symbols, nil
+}
+
+// weightBounds converts weight constraints in percent to bounds in the order of symbols and
+// checks that weights summing to 100% can satisfy them.
+func weightBounds(symbols []string, constraints []WeightConstraint) (portfolio.Bounds, error) {
+	bounds := portfolio.Bounds{Min: make([]float64, len(symbols)), Max: make([]float64, len(symbols))}
+	index := make(map[string]int, len(symbols))
+	for i, symbol := range symbols {
+		index[symbol] = i
+		bounds.Max[i] = 1
+	}
+
+	seen := make(map[string]bool, len(constraints))
+	for _, c := range constraints {
+		i, ok := index[c.Symbol]
+		if !ok {
+			return bounds, errors.New("constraint for a symbol not in symbols: " + c.Symbol)
+		}
+		if seen[c.Symbol] {
+			return bounds, errors.New("duplicate constraint for symbol: " + c.Symbol)
+		}
+		seen[c.Symbol] = true
+
+		low := applyDefault(c.Min, 0)
+		high := applyDefault(c.Max, 100)
+		if low < 0 || high > 100 || low > high {
+			return bounds, errors.New("constraints must satisfy 0 <= min <= max <= 100 for symbol: " + c.Symbol)
+		}
+		bounds.Min[i], bounds.Max[i] = low/100, high/100
+	}
+
+	// Reject minimums above 100% in total or maximums below it before running
+	return bounds, bounds.Validate(len(symbols))
+}
+
+// optimizedPortfolio reports weights as a simulation portfolio with their annual return and risk.
+func optimizedPortfolio(e portfolio.Estimates, symbols []string, weights []float64, riskFree float64) OptimizedPortfolio {
+	return OptimizedPortfolio{
+		Portfolio:      toAllocations(symbols, weights),
+		ExpectedReturn: round2(e.Return(weights) * 100),
+		Volatility:     round2(e.Volatility(weights) * 100),
+		SharpeRatio:    round2(e.Sharpe(weights, riskFree/100)),
+	}
+}
+
+// toAllocations converts weights to percentages rounded to the cent that sum to exactly 100,
+// leaving out zero weights as simulations require positive weights. Each weight is rounded
+// down, then the cents left over go to the largest remainders, so every percentage is within
+// a cent of its weight and weights within bounds set in whole cents stay within them.
+func toAllocations(symbols []string, weights []float64) []PortfolioAllocation {
+	cents := make([]float64, len(weights))
+	remainders := make([]float64, len(weights))
+	order := make([]int, len(weights))
+	total := 0.0
+	for i, w := range weights {
+		cents[i] = math.Floor(w * 10000)
+		remainders[i] = w*10000 - cents[i]
+		order[i] = i
+		total += cents[i]
+	}
+
+	slices.SortStableFunc(order, func(a, b int) int {
+		return cmp.Compare(remainders[b], remainders[a])
+	})
+	for k := 0; total < 10000 && len(order) > 0; k++ {
+		cents[order[k%len(order)]]++
+		total++
+	}
+
+	allocations := make([]PortfolioAllocation, 0, len(symbols))
+	for i, symbol := range symbols {
+		if cents[i] <= 0 {
+			continue
+		}
+		allocations = append(allocations, PortfolioAllocation{Symbol: symbol, Weight: cents[i] / 100})
+	}
+	return allocations
+}
@@ -0,0 +1,74 @@
+package handler
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/abdonasmane/etfs-simulator/backend/sdk/errors"
+)
+
+// TestToAllocations tests that weights are rounded to sum to 100 without zero weights.
+func TestToAllocations(t *testing.T) {
+	allocations := toAllocations([]string{"SPY", "QQQ", "EFA", "AGG"}, []float64{1.0 / 3, 1.0 / 3, 1.0 / 3, 1e-9})
+
+	if len(allocations) != 3 {
+		t.Fatalf("expected 3 allocations, got %+v", allocations)
+	}
+	var total float64
+	for _, a := range allocations {
+		total += a.Weight
+	}
+	if round2(total) != 100 {
+		t.Errorf("expected weights to sum to 100, got %v (%+v)", total, allocations)
+	}
+
+	// Rounding every weight to the nearest cent leaves a cent short of 100, which must not be
+	// added to the largest weight when it sits at a 40% maximum
+	allocations = toAllocations([]string{"SPY", "QQQ", "EFA", "AGG"}, []float64{0.4, 0.20004, 0.20004, 0.19992})
+	want := []float64{40, 20.01, 20, 19.99}
+	for i, a := range allocations {
+		if a.Weight != want[i] {
+			t.Errorf("expected weights %v, got %+v", want, allocations)
+			break
+		}
+	}
+}
+
+// TestWeightBounds tests the conversion of percentage constraints to bounds.
+func TestWeightBounds(t *testing.T) {
+	low, high := 10.0, 40.0
+	bounds, err := weightBounds([]string{"SPY", "QQQ"}, []WeightConstraint{{Symbol: "QQQ", Min: &low, Max: &high}})
+	if errors.Check(err) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if bounds.Min[0] != 0 || bounds.Max[0] != 1 || bounds.Min[1] != 0.1 || bounds.Max[1] != 0.4 {
+		t.Errorf("unexpected bounds: %+v", bounds)
+	}
+
+	if _, err := weightBounds([]string{"SPY", "QQQ"}, []WeightConstraint{{Symbol: "EFA", Max: &high}}); !errors.Check(err) {
+		t.Error("expected an error for a constraint on an unknown symbol")
+	}
+	if _, err := weightBounds([]string{"SPY", "QQQ"}, []WeightConstraint{{Symbol: "SPY", Min: &high, Max: &low}}); !errors.Check(err) {
+		t.Error("expected an error for min above max")
+	}
+	if _, err := weightBounds([]string{"SPY", "QQQ"}, []WeightConstraint{{Symbol: "QQQ", Max: &high}, {Symbol: "QQQ", Min: &low}}); !errors.Check(err) {
+		t.Error("expected an error for duplicate constraints")
+	}
+
+	low, high = 60, 30
+	if _, err := weightBounds([]string{"SPY", "QQQ"}, []WeightConstraint{{Symbol: "SPY", Min: &low}, {Symbol: "QQQ", Min: &low}}); !errors.Check(err) {
+		t.Error("expected an error for minimums summing above 100")
+	}
+	if _, err := weightBounds([]string{"SPY", "QQQ"}, []WeightConstraint{{Symbol: "SPY", Max: &high}, {Symbol: "QQQ", Max: &high}}); !errors.Check(err) {
+		t.Error("expected an error for maximums summing below 100")
+	}
+}
+
+// TestHandleOptimizeInfeasibleBounds tests that constraints no portfolio can satisfy are
+// rejected as a bad request.
+func TestHandleOptimizeInfeasibleBounds(t *testing.T) {
+	body := `{"symbols":["SPY","QQQ"],"constraints":[{"symbol":"SPY","min":60},{"symbol":"QQQ","min":60}]}`
+	if w := serve(newTestHandler(), "/api/v1/portfolio/optimize", body, nil); w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d: %s", w.Code, w.Body.String())
+	}
+}
@@ -0,0 +1,253 @@
+package handler
+
+import (
+	"context"
+	"math/rand/v2"
+
+	"github.com/abdonasmane/etfs-simulator/backend/sdk/errors"
+)
+
+// Probability estimation methods.
+const (
+	probabilityMethodBootstrap     = "bootstrap"
+	probabilityMethodHistorical    = "historical"
+	probabilityMethodDeterministic = "deterministic"
+)
+
+const (
+	// probabilityPaths is the number of bootstrap paths simulated.
+	probabilityPaths = 2000
+
+	// bootstrapBlockMonths is the length of each block of consecutive historical months
+	// stitched together by the bootstrap, which preserves short-term momentum.
+	bootstrapBlockMonths = 12
+
+	// minHistoricalWindows is the minimum number of historical windows required
+	// for the historical method to produce a meaningful probability.
+	minHistoricalWindows = 12
+
+	// progressPaths is the number of paths simulated between progress reports.
+	progressPaths = 100
+
+	// probabilitySeed makes bootstrap results reproducible for identical requests.
+	probabilitySeed = 0x5eed
+)
+
+// TargetProbability reports how likely a plan is to reach its target amount.
+type TargetProbability struct {
+	TargetAmount float64 `json:"targetAmount" example:"250000"`
+
+	// Probability is the percentage of paths ending at or above the target.
+	Probability float64 `json:"probability" example:"72.5"`
+
+	// Method is how paths were generated: "bootstrap", "historical" or "deterministic" (fixed rate).
+	Method string `json:"method" example:"bootstrap"`
+
+	// Paths is the number of simulated paths.
+	Paths int `json:"paths" example:"2000"`
+
+	// Curve is the probability of being at or above the target at each year and at the horizon.
+	Curve []ProbabilityPoint `json:"curve"`
+}
+
+// ProbabilityPoint is the probability of being at or above the target at a given month.
+type ProbabilityPoint struct {
+	Year          int     `json:"year" example:"2030"`
+	Month         int     `json:"month" example:"6"`
+	MonthsFromNow int     `json:"monthsFromNow" example:"60"`
+	Probability   float64 `json:"probability" example:"41.3"`
+}
+
+// estimateTargetProbability estimates the probability of reaching the plan's target amount.
+// Fixed-rate plans have a single deterministic path built from projections. Progress is
+// reported every progressPaths paths, and the estimate stops if ctx is cancelled.
+func (h *Handler) estimateTargetProbability(ctx context.Context, plan *simulationPlan, projections []MonthProjection) (*TargetProbability, error) {
+	target := *plan.targetAmount
+	reached := make([]int, plan.totalMonths)
+
+	if plan.rates == nil || onlyCash(plan.allocations) {
+		for i, p := range projections {
+			if p.PortfolioValue >= target {
+				reached[i] = 1
+			}
+		}
+		return buildTargetProbability(plan, projections, reached, 1, probabilityMethodDeterministic), nil
+	}
+
+	history, err := h.historicalReturns(plan.allocations, plan.cashRate)
+	if errors.Check(err) {
+		return nil, err
+	}
+
+	sampler, paths, err := newPathSampler(history, plan.totalMonths, plan.probabilityMethod)
+	if errors.Check(err) {
+		return nil, err
+	}
+
+	for p := 0; p < paths; p++ {
+		if err := ctx.Err(); errors.Check(err) {
+			return nil, err
+		}
+
+		values := runEngine(plan, sampler(p), false).values
+		for i, v := range values {
+			if v >= target {
+				reached[i]++
+			}
+		}
+		if (p+1)%progressPaths == 0 || p+1 == paths {
+			reportProgress(ctx, p+1, paths)
+		}
+	}
+
+	return buildTargetProbability(plan, projections, reached, paths, plan.probabilityMethod), nil
+}
+
+// returnHistory holds the aligned historical monthly returns of a plan's allocations.
+type returnHistory struct {
+	// blended is the return of a monthly-rebalanced mix of the allocations.
+	blended []float64
+
+	// symbols holds each allocation's own returns ([month][symbol]).
+	symbols [][]float64
+}
+
+// historicalReturns returns the historical monthly returns of the given allocations
+// over their shared history. The cash sleeve earns its interest rate every month.
+func (h *Handler) historicalReturns(allocations []PortfolioAllocation, cashRate *float64) (*returnHistory, error) {
+	symbols := make([]string, 0, len(allocations))
+	for _, a := range allocations {
+		if a.Symbol != cashSymbol {
+			symbols = append(symbols, a.Symbol)
+		}
+	}
+
+	matrix, err := h.indexService.AlignedMonthlyReturns(symbols)
+	if errors.Check(err) {
+		return nil, errors.Wrap(err, "historical returns unavailable")
+	}
+
+	var cashReturn float64
+	if cashRate != nil {
+		cashReturn = annualToMonthly(*cashRate)
+	}
+
+	blended := make([]float64, len(matrix.Returns))
+	returns := make([][]float64, len(matrix.Returns))
+	for t, row := range matrix.Returns {
+		returns[t] = make([]float64, len(allocations))
+		j := 0
+		for i, a := range allocations {
+			if a.Symbol == cashSymbol {
+				returns[t][i] = cashReturn
+			} else {
+				returns[t][i] = row[j]
+				j++
+			}
+			blended[t] += returns[t][i] * a.Weight / 100
+		}
+	}
+
+	return &returnHistory{blended: blended, symbols: returns}, nil
+}
+
+// pathSampler returns the p-th return path of an estimation.
+type pathSampler func(p int) returnPath
+
+// newPathSampler returns a sampler for the given method and the number of paths it provides.
+func newPathSampler(history *returnHistory, months int, method string) (pathSampler, int, error) {
+	n := len(history.blended)
+
+	switch method {
+	case probabilityMethodHistorical:
+		windows, err := historicalWindows(n, months)
+		if errors.Check(err) {
+			return nil, 0, err
+		}
+
+		sampler := func(p int) returnPath {
+			return returnPath{
+				portfolio: history.blended[p : p+months],
+				symbols:   history.symbols[p : p+months],
+			}
+		}
+		return sampler, windows, nil
+
+	default:
+		if n < bootstrapBlockMonths {
+			return nil, 0, errors.New("not enough history to estimate probability")
+		}
+
+		rng := rand.New(rand.NewPCG(probabilitySeed, uint64(months)))
+		sampler := func(int) returnPath {
+			path := returnPath{
+				portfolio: make([]float64, 0, months),
+				symbols:   make([][]float64, 0, months),
+			}
+			for _, t := range bootstrapMonths(n, months, rng) {
+				path.portfolio = append(path.portfolio, history.blended[t])
+				path.symbols = append(path.symbols, history.symbols[t])
+			}
+			return path
+		}
+		return sampler, probabilityPaths, nil
+	}
+}
+
+// historicalWindows returns the number of months-long windows in historyMonths of history,
+// or an error if there are fewer than minHistoricalWindows.
+func historicalWindows(historyMonths, months int) (int, error) {
+	windows := historyMonths - months + 1
+	if windows < minHistoricalWindows {
+		return 0, errors.Errorf("not enough history for %d-month historical windows, use probabilityMethod \"bootstrap\"", months)
+	}
+	return windows, nil
+}
+
+// bootstrapMonths picks historical month indices by stitching together randomly
+// chosen blocks of consecutive months.
+func bootstrapMonths(historyMonths, months int, rng *rand.Rand) []int {
+	indices := make([]int, 0, months)
+	for len(indices) < months {
+		start := rng.IntN(historyMonths - bootstrapBlockMonths + 1)
+		for j := 0; j < bootstrapBlockMonths && len(indices) < months; j++ {
+			indices = append(indices, start+j)
+		}
+	}
+	return indices
+}
+
+// buildTargetProbability converts per-month hit counts into the response curve,
+// sampling every 12 months plus the final month.
+func buildTargetProbability(plan *simulationPlan, projections []MonthProjection, reached []int, paths int, method string) *TargetProbability {
+	pct := func(i int) float64 {
+		return round1(float64(reached[i]) / float64(paths) * 100)
+	}
+
+	curve := []ProbabilityPoint{}
+	last := plan.totalMonths - 1
+	for i := 11; i <= last; i += 12 {
+		curve = append(curve, ProbabilityPoint{
+			Year:          projections[i].Year,
+			Month:         projections[i].Month,
+			MonthsFromNow: i + 1,
+			Probability:   pct(i),
+		})
+	}
+	if last%12 != 11 {
+		curve = append(curve, ProbabilityPoint{
+			Year:          projections[last].Year,
+			Month:         projections[last].Month,
+			MonthsFromNow: last + 1,
+			Probability:   pct(last),
+		})
+	}
+
+	return &TargetProbability{
+		TargetAmount: *plan.targetAmount,
+		Probability:  pct(last),
+		Method:       method,
+		Paths:        paths,
+		Curve:        curve,
+	}
+}
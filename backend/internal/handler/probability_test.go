@@ -0,0 +1,158 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/abdonasmane/etfs-simulator/backend/sdk/errors"
+)
+
+// simulateProbability serves a simulation request and returns its summary.
+func simulateProbability(t *testing.T, h *Handler, path, body string) SimulateSummary {
+	t.Helper()
+
+	w := serve(h, path, body, nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var resp struct {
+		Summary SimulateSummary `json:"summary"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&resp); errors.Check(err) {
+		t.Fatalf("invalid response: %v", err)
+	}
+	if resp.Summary.TargetProbability == nil {
+		t.Fatal("expected a target probability")
+	}
+	return resp.Summary
+}
+
+// TestTargetProbabilityDeterministic tests that fixed-rate and cash-only plans follow their
+// single projected path.
+func TestTargetProbabilityDeterministic(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+	}{
+		{"fixed rate", `{"years":10,"monthlyContribution":500,"annualReturnRate":7,"targetAmount":%g}`},
+		{"cash only", `{"years":10,"monthlyContribution":500,"portfolio":[{"symbol":"CASH","weight":100}],"cashInterestRate":3,"targetAmount":%g}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newTestHandler()
+			for _, target := range []float64{60000, 1000000} {
+				summary := simulateProbability(t, h, "/api/v1/simulate/years", fmt.Sprintf(tt.body, target))
+				probability := summary.TargetProbability
+
+				if probability.Method != probabilityMethodDeterministic || probability.Paths != 1 {
+					t.Errorf("expected a single deterministic path, got %s with %d paths", probability.Method, probability.Paths)
+				}
+				want := 0.0
+				if summary.FinalValue >= target {
+					want = 100
+				}
+				if probability.Probability != want {
+					t.Errorf("target %g: expected %.0f%% for a final value of %.2f, got %.1f%%", target, want, summary.FinalValue, probability.Probability)
+				}
+			}
+		})
+	}
+}
+
+// TestTargetProbabilityBootstrapSeeded tests that identical bootstrap requests give identical
+// estimates, even on handlers that do not share a cache.
+func TestTargetProbabilityBootstrapSeeded(t *testing.T) {
+	body := `{"years":15,"monthlyContribution":500,"indexSymbol":"SPY","targetAmount":150000}`
+
+	first := simulateProbability(t, newTestHandler(), "/api/v1/simulate/years", body).TargetProbability
+	second := simulateProbability(t, newTestHandler(), "/api/v1/simulate/years", body).TargetProbability
+
+	if first.Method != probabilityMethodBootstrap || first.Paths != probabilityPaths {
+		t.Errorf("expected %d bootstrap paths, got %s with %d paths", probabilityPaths, first.Method, first.Paths)
+	}
+	if !reflect.DeepEqual(first, second) {
+		t.Errorf("expected reproducible estimates, got %+v and %+v", *first, *second)
+	}
+}
+
+// TestTargetProbabilityHistoricalWindows tests that the historical method requires
+// minHistoricalWindows windows of history.
+func TestTargetProbabilityHistoricalWindows(t *testing.T) {
+	h := newTestHandler()
+	body := `{"years":%d,"monthlyContribution":500,"indexSymbol":"SPY","targetAmount":150000,"probabilityMethod":"historical"}`
+
+	// 29 years leave 13 windows in 30 years of history, 30 years only one
+	probability := simulateProbability(t, h, "/api/v1/simulate/years", fmt.Sprintf(body, 29)).TargetProbability
+	if want := testHistoryMonths - 29*12 + 1; probability.Method != probabilityMethodHistorical || probability.Paths != want {
+		t.Errorf("expected %d historical windows, got %s with %d paths", want, probability.Method, probability.Paths)
+	}
+	if w := serve(h, "/api/v1/simulate/years", fmt.Sprintf(body, 30), nil); w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 with too few windows, got %d", w.Code)
+	}
+
+	history := &returnHistory{blended: make([]float64, 100), symbols: make([][]float64, 100)}
+	if _, paths, err := newPathSampler(history, 100-minHistoricalWindows+1, probabilityMethodHistorical); errors.Check(err) || paths != minHistoricalWindows {
+		t.Errorf("expected exactly %d windows to be enough, got %d paths and %v", minHistoricalWindows, paths, err)
+	}
+	if _, _, err := newPathSampler(history, 100-minHistoricalWindows+2, probabilityMethodHistorical); !errors.Check(err) {
+		t.Errorf("expected an error with %d windows", minHistoricalWindows-1)
+	}
+}
+
+// TestTargetProbabilityCurve tests that the curve is sampled every 12 months and at the
+// final month, including a final partial year.
+func TestTargetProbabilityCurve(t *testing.T) {
+	h := newTestHandler()
+	now := time.Now()
+	end := time.Date(now.Year(), now.Month()+30, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		path   string
+		body   string
+		months []int
+	}{
+		{
+			name:   "whole years",
+			path:   "/api/v1/simulate/years",
+			body:   `{"years":2,"monthlyContribution":500,"indexSymbol":"SPY","targetAmount":10000}`,
+			months: []int{12, 24},
+		},
+		{
+			name:   "partial year",
+			path:   "/api/v1/simulate/target",
+			body:   fmt.Sprintf(`{"targetYear":%d,"targetMonth":%d,"monthlyContribution":500,"indexSymbol":"SPY","targetAmount":10000}`, end.Year(), end.Month()),
+			months: []int{12, 24, 30},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			probability := simulateProbability(t, h, tt.path, tt.body).TargetProbability
+
+			var months []int
+			for _, p := range probability.Curve {
+				months = append(months, p.MonthsFromNow)
+			}
+			if !slices.Equal(months, tt.months) {
+				t.Fatalf("expected points at months %v, got %v", tt.months, months)
+			}
+
+			last := probability.Curve[len(probability.Curve)-1]
+			want := time.Date(now.Year(), now.Month()+time.Month(last.MonthsFromNow), 1, 0, 0, 0, 0, time.UTC)
+			if last.Year != want.Year() || last.Month != int(want.Month()) {
+				t.Errorf("expected the final point in %d-%02d, got %d-%02d", want.Year(), want.Month(), last.Year, last.Month)
+			}
+			if last.Probability != probability.Probability {
+				t.Errorf("expected the final point to match the probability %.1f, got %.1f", probability.Probability, last.Probability)
+			}
+		})
+	}
+}
@@ -0,0 +1,243 @@
+package handler
+
+import (
+	"slices"
+
+	"github.com/abdonasmane/etfs-simulator/backend/sdk/errors"
+)
+
+// SalaryPlan derives monthly contributions from a salary. Raises apply every January.
+type SalaryPlan struct {
+	// AnnualSalary is the starting gross annual salary.
+	AnnualSalary float64 `json:"annualSalary" example:"60000"`
+
+	// RaiseRate is the annual raise percentage (default: 0).
+	RaiseRate *float64 `json:"raiseRate,omitempty" example:"3"`
+
+	// RaiseSchedule overrides the raise rate in specific calendar years.
+	RaiseSchedule []SalaryRaise `json:"raiseSchedule,omitempty"`
+
+	// ContributionPercent is the percentage of salary contributed by the employee.
+	ContributionPercent float64 `json:"contributionPercent" example:"8"`
+
+	// Match lists the employer match tiers in increasing UpToPercent order.
+	// "50% up to 6%" is a single tier {rate: 50, upToPercent: 6}.
+	Match []MatchTier `json:"match,omitempty"`
+
+	// Vesting lists the vested percentage of employer contributions by years of service.
+	// Employer contributions are fully vested if omitted.
+	Vesting []VestingStep `json:"vesting,omitempty"`
+
+	// ServiceYears is the years already worked for the employer when the simulation starts.
+	ServiceYears int `json:"serviceYears" example:"2"`
+}
+
+// SalaryRaise is the raise applied in January of a given year.
+type SalaryRaise struct {
+	Year    int     `json:"year" example:"2028"`
+	Percent float64 `json:"percent" example:"10"`
+}
+
+// MatchTier matches Rate percent of employee contributions between the previous tier's
+// UpToPercent and this tier's UpToPercent of salary.
+type MatchTier struct {
+	Rate        float64 `json:"rate" example:"50"`
+	UpToPercent float64 `json:"upToPercent" example:"6"`
+}
+
+// VestingStep is the vested percentage of employer contributions after AfterYears of service.
+type VestingStep struct {
+	AfterYears int     `json:"afterYears" example:"3"`
+	Percent    float64 `json:"percent" example:"100"`
+}
+
+// SalarySummary summarizes the salary-based contributions of a simulation.
+type SalarySummary struct {
+	StartingSalary             float64 `json:"startingSalary" example:"60000"`
+	FinalSalary                float64 `json:"finalSalary" example:"78275.40"`
+	TotalEmployeeContributions float64 `json:"totalEmployeeContributions" example:"52480.00"`
+	TotalEmployerContributions float64 `json:"totalEmployerContributions" example:"19680.00"`
+
+	// EmployerValue is the final value of employer contributions, of which VestedPercent is owned.
+	// Service towards vesting stops at retirement.
+	EmployerValue float64 `json:"employerValue" example:"26410.75"`
+	VestedPercent float64 `json:"vestedPercent" example:"100"`
+
+	// VestedValue is the final portfolio value excluding unvested employer money.
+	VestedValue float64 `json:"vestedValue" example:"98720.10"`
+}
+
+// monthContribution is the money contributed in one month of a run.
+type monthContribution struct {
+	employee float64
+	employer float64
+
+	// salary is the annual salary that month (zero without a salary plan).
+	salary float64
+}
+
+// applySalary validates the salary plan and stores it on the plan.
+// A salary replaces MonthlyContribution and ContributionGrowthRate.
+func applySalary(plan *simulationPlan, salary *SalaryPlan) error {
+	if salary == nil {
+		return nil
+	}
+
+	if plan.monthlyBase > 0 || plan.contributionGrowth > 0 {
+		return errors.New("salary cannot be combined with monthlyContribution or contributionGrowthRate")
+	}
+	if salary.AnnualSalary <= 0 {
+		return errors.New("salary.annualSalary must be > 0")
+	}
+	if salary.RaiseRate != nil && (*salary.RaiseRate < 0 || *salary.RaiseRate > 50) {
+		return errors.New("salary.raiseRate must be between 0 and 50")
+	}
+	for _, r := range salary.RaiseSchedule {
+		if r.Percent < -50 || r.Percent > 100 {
+			return errors.New("salary.raiseSchedule percent must be between -50 and 100")
+		}
+	}
+	if salary.ContributionPercent < 0 || salary.ContributionPercent > 100 {
+		return errors.New("salary.contributionPercent must be between 0 and 100")
+	}
+	if salary.ServiceYears < 0 {
+		return errors.New("salary.serviceYears must be >= 0")
+	}
+
+	prevUpTo := 0.0
+	for _, tier := range salary.Match {
+		if tier.Rate < 0 || tier.Rate > 200 {
+			return errors.New("salary.match rate must be between 0 and 200")
+		}
+		if tier.UpToPercent <= prevUpTo || tier.UpToPercent > 100 {
+			return errors.New("salary.match tiers must have increasing upToPercent up to 100")
+		}
+		prevUpTo = tier.UpToPercent
+	}
+
+	prevYears, prevPercent := -1, 0.0
+	for _, step := range salary.Vesting {
+		if step.AfterYears <= prevYears || step.Percent < prevPercent || step.Percent > 100 {
+			return errors.New("salary.vesting steps must have increasing afterYears and percent up to 100")
+		}
+		prevYears, prevPercent = step.AfterYears, step.Percent
+	}
+
+	plan.salary = salary
+	return nil
+}
+
+// contributionSchedule returns the contributions of each month of the plan, including any
+// extra contribution. Contributions stop once the retirement age is reached; the salary
+// is still reported.
+func (p *simulationPlan) contributionSchedule() []monthContribution {
+	schedule := make([]monthContribution, p.totalMonths)
+	if p.salary != nil {
+		p.salarySchedule(schedule)
+	} else {
+		monthlyContributionGrowth := annualToMonthly(p.contributionGrowth)
+		current := p.monthlyBase
+		for i := range schedule {
+			schedule[i].employee = current
+			current *= (1 + monthlyContributionGrowth)
+		}
+	}
+
+	if p.extraContribution > 0 {
+		for i := range schedule {
+			schedule[i].employee += p.extraContribution
+		}
+	}
+
+	for i := p.retirementMonth(); i < len(schedule); i++ {
+		schedule[i].employee = 0
+		schedule[i].employer = 0
+	}
+
+	return schedule
+}
+
+// salarySchedule fills the schedule from the salary plan.
+func (p *simulationPlan) salarySchedule(schedule []monthContribution) {
+	s := p.salary
+	raiseRate := applyDefault(s.RaiseRate, 0.0)
+	matchPercent := s.matchPercent()
+
+	salary := s.AnnualSalary
+	year, month := p.startYear, p.startMonth
+	for i := range schedule {
+		year, month = nextMonth(year, month)
+		if month == 1 {
+			raise := raiseRate
+			if j := slices.IndexFunc(s.RaiseSchedule, func(r SalaryRaise) bool { return r.Year == year }); j >= 0 {
+				raise = s.RaiseSchedule[j].Percent
+			}
+			salary *= 1 + raise/100
+		}
+
+		schedule[i] = monthContribution{
+			employee: salary / 12 * s.ContributionPercent / 100,
+			employer: salary / 12 * matchPercent / 100,
+			salary:   salary,
+		}
+	}
+}
+
+// nextMonth returns the calendar month following year/month.
+func nextMonth(year, month int) (int, int) {
+	if month == 12 {
+		return year + 1, 1
+	}
+	return year, month + 1
+}
+
+// matchPercent returns the employer contribution as a percentage of salary.
+func (s *SalaryPlan) matchPercent() float64 {
+	var match, prevUpTo float64
+	for _, tier := range s.Match {
+		band := min(s.ContributionPercent, tier.UpToPercent) - prevUpTo
+		if band <= 0 {
+			break
+		}
+		match += band * tier.Rate / 100
+		prevUpTo = tier.UpToPercent
+	}
+	return match
+}
+
+// vestedPercent returns the vested percentage of employer contributions after months of
+// service in the simulation. Service stops at retirement.
+func (s *SalaryPlan) vestedPercent(months int) float64 {
+	if len(s.Vesting) == 0 {
+		return 100
+	}
+
+	service := s.ServiceYears + months/12
+	vested := 0.0
+	for _, step := range s.Vesting {
+		if service >= step.AfterYears {
+			vested = step.Percent
+		}
+	}
+	return vested
+}
+
+// buildSalarySummary summarizes the salary-based contributions from the projections.
+func buildSalarySummary(plan *simulationPlan, projections []MonthProjection) *SalarySummary {
+	var employee, employer float64
+	for _, p := range projections {
+		employee += *p.EmployeeContribution
+		employer += *p.EmployerContribution
+	}
+
+	final := projections[len(projections)-1]
+	return &SalarySummary{
+		StartingSalary:             round2(plan.salary.AnnualSalary),
+		FinalSalary:                *final.Salary,
+		TotalEmployeeContributions: round2(employee),
+		TotalEmployerContributions: round2(employer),
+		EmployerValue:              *final.EmployerValue,
+		VestedPercent:              plan.salary.vestedPercent(plan.retirementMonth()),
+		VestedValue:                round2(final.PortfolioValue - *final.UnvestedValue),
+	}
+}
@@ -0,0 +1,106 @@
+package handler
+
+import (
+	"math"
+	"testing"
+)
+
+// TestSalaryMatchPercent tests single and tiered match formulas.
+func TestSalaryMatchPercent(t *testing.T) {
+	tests := []struct {
+		name         string
+		contribution float64
+		match        []MatchTier
+		want         float64
+	}{
+		{name: "50% up to 6%, contributing 8%", contribution: 8, match: []MatchTier{{Rate: 50, UpToPercent: 6}}, want: 3},
+		{name: "50% up to 6%, contributing 4%", contribution: 4, match: []MatchTier{{Rate: 50, UpToPercent: 6}}, want: 2},
+		{name: "100% of 3% then 50% of 2%", contribution: 5, match: []MatchTier{{Rate: 100, UpToPercent: 3}, {Rate: 50, UpToPercent: 5}}, want: 4},
+		{name: "no match", contribution: 5, want: 0},
+	}
+
+	for _, tt := range tests {
+		s := &SalaryPlan{ContributionPercent: tt.contribution, Match: tt.match}
+		if got := s.matchPercent(); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("%s: expected %.2f%%, got %.2f%%", tt.name, tt.want, got)
+		}
+	}
+}
+
+// TestContributionScheduleRaises tests that raises apply in January and the schedule overrides the rate.
+func TestContributionScheduleRaises(t *testing.T) {
+	rate := 10.0
+	plan := &simulationPlan{
+		startYear:   2025,
+		startMonth:  11,
+		totalMonths: 15,
+		salary: &SalaryPlan{
+			AnnualSalary:        12000,
+			RaiseRate:           &rate,
+			RaiseSchedule:       []SalaryRaise{{Year: 2027, Percent: 0}},
+			ContributionPercent: 10,
+		},
+	}
+
+	schedule := plan.contributionSchedule()
+	if schedule[0].salary != 12000 || math.Abs(schedule[0].employee-100) > 1e-9 {
+		t.Errorf("expected December salary 12000 and contribution 100, got %.2f and %.2f", schedule[0].salary, schedule[0].employee)
+	}
+	if math.Abs(schedule[1].salary-13200) > 1e-9 {
+		t.Errorf("expected a 10%% raise in January 2026, got %.2f", schedule[1].salary)
+	}
+	if math.Abs(schedule[13].salary-13200) > 1e-9 {
+		t.Errorf("expected no raise in January 2027, got %.2f", schedule[13].salary)
+	}
+}
+
+// TestVestedPercent tests graded vesting with prior service.
+func TestVestedPercent(t *testing.T) {
+	s := &SalaryPlan{
+		ServiceYears: 1,
+		Vesting:      []VestingStep{{AfterYears: 2, Percent: 50}, {AfterYears: 4, Percent: 100}},
+	}
+
+	for months, want := range map[int]float64{0: 0, 12: 50, 35: 50, 36: 100} {
+		if got := s.vestedPercent(months); got != want {
+			t.Errorf("after %d months: expected %.0f%%, got %.0f%%", months, want, got)
+		}
+	}
+}
+
+// TestSalaryAfterRetirement tests that contributions stop at retirement while the salary is
+// still reported, and that vesting service stops accruing.
+func TestSalaryAfterRetirement(t *testing.T) {
+	retirementAge := 60
+	plan := &simulationPlan{
+		annualRate:    5,
+		startYear:     2025,
+		startMonth:    1,
+		totalMonths:   24,
+		birthYear:     1965,
+		birthMonth:    6,
+		retirementAge: &retirementAge,
+		salary: &SalaryPlan{
+			AnnualSalary:        12000,
+			ContributionPercent: 10,
+			Match:               []MatchTier{{Rate: 100, UpToPercent: 6}},
+			Vesting:             []VestingStep{{AfterYears: 1, Percent: 100}},
+		},
+	}
+
+	projections := simulateMonthly(plan, scenarioMedian)
+	for i, p := range projections {
+		// June 2025 (i = 4) is the 60th birthday
+		working := i < 4
+		if *p.Salary != 12000 || (*p.EmployeeContribution > 0) != working || (*p.EmployerContribution > 0) != working {
+			t.Errorf("month %d: expected salary 12000 with contributions %v, got %.2f, %.2f and %.2f", i, working, *p.Salary, *p.EmployeeContribution, *p.EmployerContribution)
+		}
+	}
+
+	// Four months of service never reach the one-year vesting step
+	summary := buildSalarySummary(plan, projections)
+	final := projections[len(projections)-1]
+	if summary.FinalSalary != 12000 || summary.VestedPercent != 0 || *final.UnvestedValue != *final.EmployerValue {
+		t.Errorf("expected a final salary of 12000 with nothing vested, got %+v", *summary)
+	}
+}
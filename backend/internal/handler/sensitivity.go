@@ -0,0 +1,485 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"math"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/abdonasmane/etfs-simulator/backend/sdk/errors"
+)
+
+// Sensitivity variables.
+const (
+	sensitivityReturnRate          = "returnRate"
+	sensitivityMonthlyContribution = "monthlyContribution"
+	sensitivityContributionGrowth  = "contributionGrowth"
+	sensitivityYears               = "years"
+	sensitivityFees                = "fees"
+	sensitivityInflation           = "inflation"
+)
+
+// maxGridValues is the maximum number of values on a grid axis.
+const maxGridValues = 20
+
+// --- Request Types ---
+
+// SensitivityRequest is the input for analyzing how the final value responds to each input.
+// Variables are "returnRate" (annual %), "monthlyContribution", "contributionGrowth" (annual %),
+// "years", "fees" (expense ratio %) and "inflation" (decumulation spending indexation %).
+type SensitivityRequest struct {
+	SimulationInputs
+
+	// Years is the number of years to simulate (1-50).
+	Years int `json:"years" example:"30"`
+
+	// ExpenseRatio is the base annual fund fee percentage deducted from returns (default: 0).
+	ExpenseRatio *float64 `json:"expenseRatio,omitempty" example:"0.2"`
+
+	// Variables lists the inputs perturbed in the tornado (default: every one that applies to the plan).
+	// monthlyContribution and contributionGrowth do not apply with Salary, inflation requires Decumulation.
+	Variables []string `json:"variables,omitempty" example:"returnRate,monthlyContribution"`
+
+	// Deltas overrides how far each variable is moved down and up, in its own unit. Defaults:
+	// returnRate 1, monthlyContribution 10% of the contribution (at least 50), contributionGrowth 1,
+	// years 2, fees 0.25, inflation 1.
+	Deltas map[string]float64 `json:"deltas,omitempty"`
+
+	// Grid requests final values over every combination of two variables' values.
+	Grid *SensitivityGrid `json:"grid,omitempty"`
+}
+
+// SensitivityGrid is a 2D grid of two variables' values.
+type SensitivityGrid struct {
+	X GridAxis `json:"x"`
+	Y GridAxis `json:"y"`
+}
+
+// GridAxis lists the values of a variable on a grid axis.
+type GridAxis struct {
+	Variable string    `json:"variable" example:"returnRate"`
+	Values   []float64 `json:"values" example:"5,6,7,8"`
+}
+
+// --- Response Types ---
+
+// SensitivityResponse is the output for sensitivity analysis.
+type SensitivityResponse struct {
+	Inputs SensitivityRequest `json:"inputs"`
+
+	// BaseFinalValue is the final (median) value of the unperturbed plan.
+	BaseFinalValue float64 `json:"baseFinalValue" example:"612400.50"`
+
+	// Tornado lists the perturbed variables by decreasing impact.
+	Tornado []SensitivityBar `json:"tornado"`
+
+	// Grid is present when a grid was requested.
+	Grid *SensitivityGridResult `json:"grid,omitempty"`
+}
+
+// SensitivityBar is the final value with a variable moved down and up.
+type SensitivityBar struct {
+	Variable  string  `json:"variable" example:"returnRate"`
+	BaseValue float64 `json:"baseValue" example:"7"`
+	LowValue  float64 `json:"lowValue" example:"6"`
+	HighValue float64 `json:"highValue" example:"8"`
+
+	LowFinalValue  float64 `json:"lowFinalValue" example:"520100.30"`
+	HighFinalValue float64 `json:"highFinalValue" example:"725300.80"`
+
+	// LowChange and HighChange are the final values minus BaseFinalValue.
+	LowChange  float64 `json:"lowChange" example:"-92300.20"`
+	HighChange float64 `json:"highChange" example:"112900.30"`
+
+	// Impact is the spread between the high and low final values.
+	Impact float64 `json:"impact" example:"205200.50"`
+}
+
+// SensitivityGridResult holds the final values of a 2D grid.
+type SensitivityGridResult struct {
+	X       string    `json:"x" example:"returnRate"`
+	Y       string    `json:"y" example:"monthlyContribution"`
+	XValues []float64 `json:"xValues" example:"5,6,7,8"`
+	YValues []float64 `json:"yValues" example:"300,500,700"`
+
+	// FinalValues holds one row per Y value, with one final value per X value.
+	FinalValues [][]float64 `json:"finalValues"`
+}
+
+// --- Handler ---
+
+// handleSensitivity reports how sensitive the final value is to each input.
+//
+//	@Summary		Sensitivity analysis
+//	@Description	Perturbs each input down and up around a base plan and reports the change in final value sorted by impact, with an optional 2D grid of two inputs for heatmaps
+//	@Tags			simulation
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		SensitivityRequest	true	"Plan and sensitivity parameters"
+//	@Param			If-None-Match	header		string	false	"ETag of a previous identical request"
+//	@Success		200		{object}	SensitivityResponse
+//	@Header			200		{string}	ETag	"Validator for If-None-Match"
+//	@Success		304		"Not Modified"
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		500		{object}	ErrorResponse
+//	@Router			/api/v1/simulate/sensitivity [post]
+func (h *Handler) handleSensitivity(w http.ResponseWriter, r *http.Request) {
+	var req SensitivityRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); errors.Check(err) {
+		respondError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	respondCached(h, w, r, cacheEndpointSensitivity, &req, h.prepareSensitivity, func(resp *SensitivityResponse) {
+		slog.Debug("sensitivity analysis completed",
+			slog.Int("years", resp.Inputs.Years),
+			slog.Int("variables", len(resp.Tornado)),
+			slog.Bool("grid", resp.Grid != nil),
+			slog.Float64("base_final_value", resp.BaseFinalValue),
+		)
+	})
+}
+
+// --- Analysis ---
+
+// sensitivityCase is a plan with the inputs that are applied to its return path.
+type sensitivityCase struct {
+	plan simulationPlan
+
+	// returnRate is the annual median return; returns are shifted by its difference from the plan's.
+	returnRate   float64
+	expenseRatio float64
+}
+
+// sensitivityVariable describes how to read and change one input of a case.
+type sensitivityVariable struct {
+	min, max float64
+	integer  bool
+
+	applies func(p *simulationPlan) bool
+	delta   func(c *sensitivityCase) float64
+	get     func(c *sensitivityCase) float64
+	set     func(c *sensitivityCase, v float64)
+}
+
+// sensitivityVariableNames lists the variables in their default tornado order.
+var sensitivityVariableNames = []string{
+	sensitivityReturnRate,
+	sensitivityMonthlyContribution,
+	sensitivityContributionGrowth,
+	sensitivityYears,
+	sensitivityFees,
+	sensitivityInflation,
+}
+
+// anyPlan applies to every plan.
+func anyPlan(*simulationPlan) bool { return true }
+
+// withoutSalary applies to plans whose contributions are not derived from a salary.
+func withoutSalary(p *simulationPlan) bool { return p.salary == nil }
+
+// sensitivityVariables describes each variable by name.
+var sensitivityVariables = map[string]sensitivityVariable{
+	sensitivityReturnRate: {
+		min: -20, max: 30,
+		applies: anyPlan,
+		delta:   func(*sensitivityCase) float64 { return 1 },
+		get:     func(c *sensitivityCase) float64 { return c.returnRate },
+		set:     func(c *sensitivityCase, v float64) { c.returnRate = v },
+	},
+	sensitivityMonthlyContribution: {
+		min: 0, max: math.MaxFloat64,
+		applies: withoutSalary,
+		delta:   func(c *sensitivityCase) float64 { return max(c.plan.monthlyBase*0.1, 50) },
+		get:     func(c *sensitivityCase) float64 { return c.plan.monthlyBase },
+		set:     func(c *sensitivityCase, v float64) { c.plan.monthlyBase = v },
+	},
+	sensitivityContributionGrowth: {
+		min: 0, max: 20,
+		applies: withoutSalary,
+		delta:   func(*sensitivityCase) float64 { return 1 },
+		get:     func(c *sensitivityCase) float64 { return c.plan.contributionGrowth },
+		set:     func(c *sensitivityCase, v float64) { c.plan.contributionGrowth = v },
+	},
+	sensitivityYears: {
+		min: 1, max: 50, integer: true,
+		applies: anyPlan,
+		delta:   func(*sensitivityCase) float64 { return 2 },
+		get:     func(c *sensitivityCase) float64 { return float64(c.plan.totalMonths / 12) },
+		set: func(c *sensitivityCase, v float64) {
+			c.plan.totalMonths = int(v) * 12
+			c.plan.endYear = c.plan.startYear + int(v)
+		},
+	},
+	sensitivityFees: {
+		min: 0, max: 5,
+		applies: anyPlan,
+		delta:   func(*sensitivityCase) float64 { return 0.25 },
+		get:     func(c *sensitivityCase) float64 { return c.expenseRatio },
+		set:     func(c *sensitivityCase, v float64) { c.expenseRatio = v },
+	},
+	sensitivityInflation: {
+		min: 0, max: 20,
+		applies: func(p *simulationPlan) bool { return p.decumulation != nil },
+		delta:   func(*sensitivityCase) float64 { return 1 },
+		get:     func(c *sensitivityCase) float64 { return c.plan.decumulation.inflation },
+		set: func(c *sensitivityCase, v float64) {
+			d := *c.plan.decumulation
+			d.inflation = v
+			c.plan.decumulation = &d
+		},
+	},
+}
+
+// prepareSensitivity validates a sensitivity request and builds its base case. Its run
+// computes the tornado and grid, reporting progress per tornado variable and grid row, and
+// stops if ctx is cancelled.
+func (h *Handler) prepareSensitivity(req *SensitivityRequest, now time.Time) (func(context.Context) (*SensitivityResponse, error), error) {
+	if req.Years < 1 || req.Years > 50 {
+		return nil, errors.New("years must be between 1 and 50")
+	}
+
+	expenseRatio := applyDefault(req.ExpenseRatio, 0.0)
+	if expenseRatio < 0 || expenseRatio > 5 {
+		return nil, errors.New("expenseRatio must be between 0 and 5")
+	}
+	req.ExpenseRatio = &expenseRatio
+
+	startYear := now.Year()
+	startMonth := int(now.Month())
+	plan, err := h.newPlan(&req.SimulationInputs, startYear, startMonth, req.Years*12, startYear+req.Years, startMonth)
+	if errors.Check(err) {
+		return nil, err
+	}
+	base := sensitivityCase{plan: *plan, returnRate: plan.annualRate, expenseRatio: expenseRatio}
+
+	// Default to every variable that applies
+	if len(req.Variables) == 0 {
+		for _, name := range sensitivityVariableNames {
+			if sensitivityVariables[name].applies(plan) {
+				req.Variables = append(req.Variables, name)
+			}
+		}
+	}
+	for _, name := range req.Variables {
+		if err := checkSensitivityVariable(name, plan); errors.Check(err) {
+			return nil, err
+		}
+	}
+	for name := range req.Deltas {
+		if err := checkSensitivityVariable(name, plan); errors.Check(err) {
+			return nil, err
+		}
+	}
+	deltas := make(map[string]float64, len(req.Variables))
+	for _, name := range req.Variables {
+		delta, err := sensitivityDelta(&base, name, req.Deltas)
+		if errors.Check(err) {
+			return nil, err
+		}
+		deltas[name] = delta
+	}
+
+	var x, y sensitivityVariable
+	if req.Grid != nil {
+		if req.Grid.X.Variable == req.Grid.Y.Variable {
+			return nil, errors.New("grid axes must use different variables")
+		}
+		if x, err = gridVariable(req.Grid.X, plan); errors.Check(err) {
+			return nil, err
+		}
+		if y, err = gridVariable(req.Grid.Y, plan); errors.Check(err) {
+			return nil, err
+		}
+	}
+
+	return func(ctx context.Context) (*SensitivityResponse, error) {
+		baseFinal := base.finalValue()
+		resp := &SensitivityResponse{
+			Inputs:         *req,
+			BaseFinalValue: round2(baseFinal),
+			Tornado:        make([]SensitivityBar, 0, len(req.Variables)),
+		}
+
+		steps := len(req.Variables)
+		if req.Grid != nil {
+			steps += len(req.Grid.Y.Values)
+		}
+		completed := 0
+		step := func() error {
+			if err := ctx.Err(); errors.Check(err) {
+				return err
+			}
+			completed++
+			reportProgress(ctx, completed, steps)
+			return nil
+		}
+
+		for _, name := range req.Variables {
+			resp.Tornado = append(resp.Tornado, tornadoBar(base, name, deltas[name], baseFinal))
+
+			if err := step(); errors.Check(err) {
+				return nil, err
+			}
+		}
+		slices.SortStableFunc(resp.Tornado, func(a, b SensitivityBar) int {
+			switch {
+			case a.Impact > b.Impact:
+				return -1
+			case a.Impact < b.Impact:
+				return 1
+			}
+			return 0
+		})
+
+		if req.Grid != nil {
+			grid, err := sensitivityGrid(base, req.Grid, x, y, step)
+			if errors.Check(err) {
+				return nil, err
+			}
+			resp.Grid = grid
+		}
+
+		return resp, nil
+	}, nil
+}
+
+// checkSensitivityVariable returns an error if a variable is unknown or does not apply to the plan.
+func checkSensitivityVariable(name string, plan *simulationPlan) error {
+	v, ok := sensitivityVariables[name]
+	if !ok {
+		return errors.New("unknown sensitivity variable: " + name)
+	}
+	if !v.applies(plan) {
+		return errors.New("sensitivity variable does not apply to this plan: " + name)
+	}
+	return nil
+}
+
+// sensitivityDelta returns the requested or default delta of a variable and validates it.
+func sensitivityDelta(base *sensitivityCase, name string, deltas map[string]float64) (float64, error) {
+	v := sensitivityVariables[name]
+
+	delta, ok := deltas[name]
+	if !ok {
+		delta = v.delta(base)
+	}
+	if delta <= 0 || (v.integer && delta != math.Trunc(delta)) {
+		return 0, errors.New("delta must be a positive amount (a whole number for years): " + name)
+	}
+	return delta, nil
+}
+
+// tornadoBar moves a variable down and up by its delta, within its valid range.
+func tornadoBar(base sensitivityCase, name string, delta, baseFinal float64) SensitivityBar {
+	v := sensitivityVariables[name]
+	value := v.get(&base)
+	low := max(value-delta, v.min)
+	high := min(value+delta, v.max)
+
+	lowFinal := base.with(v, low).finalValue()
+	highFinal := base.with(v, high).finalValue()
+
+	return SensitivityBar{
+		Variable:       name,
+		BaseValue:      round2(value),
+		LowValue:       round2(low),
+		HighValue:      round2(high),
+		LowFinalValue:  round2(lowFinal),
+		HighFinalValue: round2(highFinal),
+		LowChange:      round2(lowFinal - baseFinal),
+		HighChange:     round2(highFinal - baseFinal),
+		Impact:         round2(math.Abs(highFinal - lowFinal)),
+	}
+}
+
+// sensitivityGrid computes the final value for every combination of the grid's values, with
+// x and y the variables of its validated axes. step is called after each row and stops the
+// grid when it returns an error.
+func sensitivityGrid(base sensitivityCase, grid *SensitivityGrid, x, y sensitivityVariable, step func() error) (*SensitivityGridResult, error) {
+	result := &SensitivityGridResult{
+		X:           grid.X.Variable,
+		Y:           grid.Y.Variable,
+		XValues:     grid.X.Values,
+		YValues:     grid.Y.Values,
+		FinalValues: make([][]float64, len(grid.Y.Values)),
+	}
+	for j, yv := range grid.Y.Values {
+		row := base.with(y, yv)
+		result.FinalValues[j] = make([]float64, len(grid.X.Values))
+		for i, xv := range grid.X.Values {
+			result.FinalValues[j][i] = round2(row.with(x, xv).finalValue())
+		}
+
+		if err := step(); errors.Check(err) {
+			return nil, err
+		}
+	}
+
+	return result, nil
+}
+
+// gridVariable validates a grid axis and returns its variable.
+func gridVariable(axis GridAxis, plan *simulationPlan) (sensitivityVariable, error) {
+	if err := checkSensitivityVariable(axis.Variable, plan); errors.Check(err) {
+		return sensitivityVariable{}, err
+	}
+	if len(axis.Values) == 0 || len(axis.Values) > maxGridValues {
+		return sensitivityVariable{}, errors.Errorf("grid axes must have between 1 and %d values", maxGridValues)
+	}
+
+	v := sensitivityVariables[axis.Variable]
+	for _, value := range axis.Values {
+		if value < v.min || value > v.max || (v.integer && value != math.Trunc(value)) {
+			return sensitivityVariable{}, errors.New("grid value out of range for " + axis.Variable)
+		}
+	}
+	return v, nil
+}
+
+// with returns a copy of the case with a variable set to a value.
+func (c sensitivityCase) with(v sensitivityVariable, value float64) sensitivityCase {
+	v.set(&c, value)
+	return c
+}
+
+// finalValue runs the case along its median path and returns the final value.
+func (c sensitivityCase) finalValue() float64 {
+	path := c.plan.scenarioPath(scenarioMedian)
+	if shift := c.returnRate - c.plan.annualRate; shift != 0 {
+		path = shiftReturns(path, shift)
+	}
+	if c.expenseRatio > 0 {
+		path = withExpenseRatio(path, c.expenseRatio)
+	}
+
+	values := runEngine(&c.plan, path, false).values
+	return values[len(values)-1]
+}
+
+// shiftReturns returns a copy of a return path with shift added to every annual rate.
+func shiftReturns(path returnPath, shift float64) returnPath {
+	adjust := func(r float64) float64 {
+		return annualToMonthly((math.Pow(1+r, 12)-1)*100 + shift)
+	}
+
+	shifted := returnPath{portfolio: make([]float64, len(path.portfolio))}
+	for i, r := range path.portfolio {
+		shifted.portfolio[i] = adjust(r)
+	}
+
+	if path.symbols != nil {
+		shifted.symbols = make([][]float64, len(path.symbols))
+		for i, month := range path.symbols {
+			shifted.symbols[i] = make([]float64, len(month))
+			for j, r := range month {
+				shifted.symbols[i][j] = adjust(r)
+			}
+		}
+	}
+
+	return shifted
+}
@@ -0,0 +1,82 @@
+package handler
+
+import (
+	"encoding/json"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/abdonasmane/etfs-simulator/backend/sdk/errors"
+)
+
+// TestShiftReturns tests that shifting adds to the annual rate of every month.
+func TestShiftReturns(t *testing.T) {
+	path := returnPath{portfolio: constantReturns(5, 2), symbols: [][]float64{{annualToMonthly(5)}}}
+
+	shifted := shiftReturns(path, 2)
+
+	if math.Abs(shifted.portfolio[1]-annualToMonthly(7)) > 1e-12 || math.Abs(shifted.symbols[0][0]-annualToMonthly(7)) > 1e-12 {
+		t.Errorf("expected 7%% monthly returns, got %v and %v", shifted.portfolio, shifted.symbols)
+	}
+}
+
+// TestTornadoBar tests that a variable is moved down and up within its valid range.
+func TestTornadoBar(t *testing.T) {
+	base := sensitivityCase{plan: simulationPlan{monthlyBase: 100, startYear: 2025, startMonth: 12, totalMonths: 12}}
+	baseFinal := base.finalValue()
+
+	delta, err := sensitivityDelta(&base, sensitivityMonthlyContribution, nil)
+	if errors.Check(err) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	bar := tornadoBar(base, sensitivityMonthlyContribution, delta, baseFinal)
+
+	// The default delta is at least 50, and contributions cannot go below 0
+	if bar.LowValue != 50 || bar.HighValue != 150 || bar.LowChange != -600 || bar.HighChange != 600 || bar.Impact != 1200 {
+		t.Errorf("unexpected bar: %+v", bar)
+	}
+
+	bar = tornadoBar(base, sensitivityMonthlyContribution, 200, baseFinal)
+	if bar.LowValue != 0 || bar.LowFinalValue != 0 {
+		t.Errorf("expected the contribution to stop at 0, got %+v", bar)
+	}
+}
+
+// TestPrepareSensitivity tests that deltas and grid axes are validated before running.
+func TestPrepareSensitivity(t *testing.T) {
+	h := newTestHandler()
+
+	tests := []struct {
+		name    string
+		body    string
+		wantErr string
+	}{
+		{"valid", `{"years":10,"monthlyContribution":100,"grid":{"x":{"variable":"returnRate","values":[4,6]},"y":{"variable":"years","values":[5,10]}}}`, ""},
+		{"negative delta", `{"years":10,"monthlyContribution":100,"deltas":{"returnRate":-1}}`, "delta must be a positive amount"},
+		{"fractional years delta", `{"years":10,"monthlyContribution":100,"deltas":{"years":1.5}}`, "delta must be a positive amount"},
+		{"same grid axes", `{"years":10,"grid":{"x":{"variable":"years","values":[5]},"y":{"variable":"years","values":[10]}}}`, "grid axes must use different variables"},
+		{"empty grid axis", `{"years":10,"grid":{"x":{"variable":"returnRate","values":[]},"y":{"variable":"years","values":[10]}}}`, "grid axes must have"},
+		{"grid value out of range", `{"years":10,"grid":{"x":{"variable":"returnRate","values":[5]},"y":{"variable":"years","values":[80]}}}`, "grid value out of range"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var req SensitivityRequest
+			if err := json.Unmarshal([]byte(tt.body), &req); errors.Check(err) {
+				t.Fatalf("invalid request: %v", err)
+			}
+
+			_, err := h.prepareSensitivity(&req, time.Now())
+			if tt.wantErr == "" {
+				if errors.Check(err) {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if !errors.Check(err) || !strings.HasPrefix(err.Error(), tt.wantErr) {
+				t.Errorf("expected an error starting with %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,320 @@
+package handler
+
+import (
+	"math"
+
+	"github.com/abdonasmane/etfs-simulator/backend/sdk/errors"
+)
+
+// Share modes.
+const (
+	shareModeWhole      = "whole"
+	shareModeFractional = "fractional"
+)
+
+// ShareHolding is the position held in one ETF at the end of a month.
+type ShareHolding struct {
+	Symbol string  `json:"symbol" example:"SPY"`
+	Shares float64 `json:"shares" example:"12"`
+	Price  float64 `json:"price" example:"612.40"`
+	Value  float64 `json:"value" example:"7348.80"`
+
+	// Invested is the amount spent buying the shares held, commissions included.
+	Invested float64 `json:"invested" example:"6950.00"`
+}
+
+// shareConfig holds the validated share mode settings of a plan.
+type shareConfig struct {
+	whole   bool
+	symbols []string
+	weights []float64 // decimal target weights
+	prices  []float64 // starting prices
+}
+
+// applyShareMode validates the share mode options and stores them on the plan.
+// Prices default to each ETF's last close and can be overridden per symbol.
+func (h *Handler) applyShareMode(plan *simulationPlan, mode *string, prices map[string]float64) error {
+	if mode == nil || *mode == "" {
+		return nil
+	}
+
+	if *mode != shareModeWhole && *mode != shareModeFractional {
+		return errors.New("shareMode must be \"whole\" or \"fractional\"")
+	}
+	if plan.rates == nil {
+		return errors.New("shareMode requires indexSymbol or portfolio")
+	}
+
+	cfg := &shareConfig{whole: *mode == shareModeWhole}
+	for _, a := range plan.allocations {
+		price, ok := prices[a.Symbol]
+		if !ok {
+			if info, err := h.lookupIndex(a.Symbol, plan.cashRate); !errors.Check(err) {
+				price = info.LastPrice
+			}
+		}
+		if price <= 0 {
+			return errors.New("no share price available for symbol: " + a.Symbol)
+		}
+
+		cfg.symbols = append(cfg.symbols, a.Symbol)
+		cfg.weights = append(cfg.weights, a.Weight/100)
+		cfg.prices = append(cfg.prices, price)
+	}
+
+	plan.shares = cfg
+	return nil
+}
+
+// shareBook tracks share counts, prices and uninvested cash during a run.
+// Contributions are spent on the ETFs furthest below their target weight,
+// so holdings drift with prices and are only rebalanced through new money.
+type shareBook struct {
+	cfg      *shareConfig
+	prices   []float64
+	shares   []float64
+	invested []float64
+	cash     float64
+
+	// commission is charged once per ETF bought in a purchase (nil for no fees).
+	commission *CommissionSchedule
+
+	// ledger records each purchase as a tax lot, if set.
+	ledger *costLedger
+}
+
+// newShareBook creates an empty book at the configured starting prices.
+func newShareBook(cfg *shareConfig) *shareBook {
+	n := len(cfg.symbols)
+	b := &shareBook{
+		cfg:      cfg,
+		prices:   make([]float64, n),
+		shares:   make([]float64, n),
+		invested: make([]float64, n),
+	}
+	copy(b.prices, cfg.prices)
+	return b
+}
+
+// holdingsValue returns the market value of the shares held, excluding cash.
+func (b *shareBook) holdingsValue() float64 {
+	var total float64
+	for i, s := range b.shares {
+		total += s * b.prices[i]
+	}
+	return total
+}
+
+// applyReturns moves prices by one month of returns and returns the change in value.
+// Uninvested cash earns nothing.
+func (b *shareBook) applyReturns(returns []float64) float64 {
+	var growth float64
+	for i, r := range returns {
+		growth += b.shares[i] * b.prices[i] * r
+		b.prices[i] *= 1 + r
+	}
+	return growth
+}
+
+// invest adds cash and buys shares with as much of the available cash as possible,
+// placing at most one order per ETF. It returns the commissions paid.
+func (b *shareBook) invest(amount float64) float64 {
+	b.cash += amount
+	if b.cash <= 0 {
+		return 0
+	}
+
+	// Spend cash on each ETF's shortfall from its target weight
+	orders := make([]float64, len(b.shares))
+	total := b.holdingsValue() + b.cash
+	deficits := b.deficits(total, orders)
+	var sumDeficit float64
+	for _, d := range deficits {
+		sumDeficit += d
+	}
+
+	for i, d := range deficits {
+		budget := b.cash * b.cfg.weights[i]
+		if sumDeficit > 0 {
+			budget = b.cash * d / sumDeficit
+		}
+		orders[i] = b.sharesFor(i, budget, tradeIndex(orders, i))
+	}
+
+	if b.cfg.whole {
+		// Add leftover cash one share at a time to the most underweight ETF, or keep
+		// saving for it when its next share is not affordable yet
+		remaining := b.cash
+		for i := range orders {
+			remaining -= b.orderCost(i, orders[i], tradeIndex(orders, i))
+		}
+
+		for {
+			deficits = b.deficits(total, orders)
+			best := -1
+			for i, d := range deficits {
+				if d > 0 && (best < 0 || d > deficits[best]) {
+					best = i
+				}
+			}
+			if best < 0 {
+				break
+			}
+
+			trade := tradeIndex(orders, best)
+			extra := b.orderCost(best, orders[best]+1, trade) - b.orderCost(best, orders[best], trade)
+			if extra > remaining+1e-9 {
+				break
+			}
+			orders[best]++
+			remaining -= extra
+		}
+	}
+
+	return b.execute(orders)
+}
+
+// withdraw takes amount from cash first, then sells every ETF in proportion to its value.
+// Whole shares are rounded up and the excess proceeds are kept as cash.
+func (b *shareBook) withdraw(amount float64) {
+	fromCash := math.Min(b.cash, amount)
+	b.cash -= fromCash
+	amount -= fromCash
+
+	holdings := b.holdingsValue()
+	if amount <= 0 || holdings <= 0 {
+		return
+	}
+
+	for i, s := range b.shares {
+		shares := amount * s / holdings
+		if b.cfg.whole {
+			shares = math.Min(s, math.Ceil(shares-1e-9))
+		}
+		if shares <= 0 {
+			continue
+		}
+
+		b.invested[i] *= 1 - shares/s
+		b.shares[i] -= shares
+		b.cash += shares * b.prices[i]
+		if b.ledger != nil {
+			b.ledger.sell(i, shares)
+		}
+	}
+	b.cash = math.Max(0, b.cash-amount)
+}
+
+// deficits returns how far each ETF, including pending orders, is below its target value (zero if above).
+func (b *shareBook) deficits(total float64, orders []float64) []float64 {
+	deficits := make([]float64, len(b.shares))
+	for i, s := range b.shares {
+		deficits[i] = math.Max(0, b.cfg.weights[i]*total-(s+orders[i])*b.prices[i])
+	}
+	return deficits
+}
+
+// sharesFor returns how many shares of ETF i a budget buys after commission,
+// rounding down to whole shares if required.
+func (b *shareBook) sharesFor(i int, budget float64, trade int) float64 {
+	budget = math.Min(budget, b.cash)
+
+	// The fee on the full budget is never below the fee on what is actually bought
+	shares := (budget - b.commission.fee(budget, trade)) / b.prices[i]
+	if b.cfg.whole {
+		// Tolerate floating point noise when the budget is an exact multiple of the price
+		shares = math.Floor(shares + 1e-9)
+	}
+	return math.Max(0, shares)
+}
+
+// orderCost returns the cash needed to buy shares of ETF i, commission included.
+func (b *shareBook) orderCost(i int, shares float64, trade int) float64 {
+	if shares <= 0 {
+		return 0
+	}
+	cost := shares * b.prices[i]
+	return cost + b.commission.fee(cost, trade)
+}
+
+// execute fills the orders in symbol order and returns the commissions paid.
+func (b *shareBook) execute(orders []float64) float64 {
+	var fees float64
+	trade := 0
+	for i, shares := range orders {
+		if shares <= 0 {
+			continue
+		}
+
+		// Orders are sized to fit the cash; shrink any that no longer do after rounding
+		if b.orderCost(i, shares, trade) > b.cash+1e-9 {
+			shares = b.sharesFor(i, b.cash, trade)
+			if shares <= 0 {
+				continue
+			}
+		}
+
+		cost := shares * b.prices[i]
+		fee := b.commission.fee(cost, trade)
+		b.shares[i] += shares
+		b.invested[i] += cost + fee
+		if b.ledger != nil {
+			b.ledger.buy(i, shares, cost+fee)
+		}
+		b.cash = math.Max(0, b.cash-cost-fee)
+		fees += fee
+		trade++
+	}
+	return fees
+}
+
+// tradeIndex returns the position of ETF i's order among the orders of a purchase,
+// which decides whether it is one of the month's free trades.
+func tradeIndex(orders []float64, i int) int {
+	trade := 0
+	for _, shares := range orders[:i] {
+		if shares > 0 {
+			trade++
+		}
+	}
+	return trade
+}
+
+// record writes the book's positions into a projection.
+func (b *shareBook) record(p *MonthProjection) {
+	p.Shares = make([]ShareHolding, len(b.shares))
+	p.Holdings = make([]SymbolValue, len(b.shares))
+
+	for i, symbol := range b.cfg.symbols {
+		value := round2(b.shares[i] * b.prices[i])
+		p.Shares[i] = ShareHolding{
+			Symbol:   symbol,
+			Shares:   math.Round(b.shares[i]*1e6) / 1e6,
+			Price:    round2(b.prices[i]),
+			Value:    value,
+			Invested: round2(b.invested[i]),
+		}
+		p.Holdings[i] = SymbolValue{Symbol: symbol, Value: value}
+	}
+}
+
+// shareBreakdown completes the portfolio breakdown from the final share positions.
+func shareBreakdown(plan *simulationPlan, projections []MonthProjection) []PortfolioBreakdown {
+	final := projections[len(projections)-1]
+
+	breakdown := make([]PortfolioBreakdown, len(plan.portfolio.breakdown))
+	copy(breakdown, plan.portfolio.breakdown)
+
+	// Positions are recorded in allocation order, like the breakdown
+	for i := range breakdown {
+		s := final.Shares[i]
+		breakdown[i].FinalValue = s.Value
+		breakdown[i].TotalContributed = s.Invested
+		breakdown[i].TotalGain = round2(s.Value - s.Invested)
+		if final.PortfolioValue > 0 {
+			breakdown[i].ValueShare = round1(s.Value / final.PortfolioValue * 100)
+		}
+	}
+
+	return breakdown
+}
@@ -0,0 +1,51 @@
+package handler
+
+import (
+	"math"
+	"testing"
+)
+
+// TestShareBookWholeShares tests that whole shares are bought and leftover cash is carried.
+func TestShareBookWholeShares(t *testing.T) {
+	book := newShareBook(&shareConfig{
+		whole:   true,
+		symbols: []string{"AAA"},
+		weights: []float64{1},
+		prices:  []float64{300},
+	})
+
+	book.invest(500)
+	if book.shares[0] != 1 {
+		t.Fatalf("expected 1 share, got %.4f", book.shares[0])
+	}
+	if math.Abs(book.cash-200) > 1e-9 {
+		t.Errorf("expected 200 leftover cash, got %.2f", book.cash)
+	}
+
+	// Leftover cash is spent once another share becomes affordable
+	book.invest(100)
+	if book.shares[0] != 2 || book.cash > 1e-9 {
+		t.Errorf("expected 2 shares and no cash, got %.4f shares and %.2f cash", book.shares[0], book.cash)
+	}
+}
+
+// TestShareBookTargetsWeights tests that contributions go to underweight ETFs.
+func TestShareBookTargetsWeights(t *testing.T) {
+	book := newShareBook(&shareConfig{
+		symbols: []string{"AAA", "BBB"},
+		weights: []float64{0.5, 0.5},
+		prices:  []float64{10, 10},
+	})
+
+	book.invest(1000)
+	book.applyReturns([]float64{1, 0}) // AAA doubles
+	book.invest(500)
+
+	// AAA is worth 1000, BBB 500: the whole contribution should go to BBB
+	if math.Abs(book.shares[0]-50) > 1e-9 || math.Abs(book.shares[1]-100) > 1e-9 {
+		t.Errorf("expected 50 AAA and 100 BBB shares, got %.4f and %.4f", book.shares[0], book.shares[1])
+	}
+	if book.cash > 1e-9 {
+		t.Errorf("fractional mode should invest all cash, got %.2f", book.cash)
+	}
+}
@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"context"
 	"encoding/json"
 	"log/slog"
 	"math"
@@ -21,20 +22,21 @@ type PortfolioAllocation struct {
 	Weight float64 `json:"weight" example:"60"`
 }
 
-// SimulateByYearsRequest is the input for simulating by number of years.
-type SimulateByYearsRequest struct {
+// SimulationInputs contains the plan parameters shared by all simulation requests.
+type SimulationInputs struct {
 	// InitialInvestment is the starting amount.
 	InitialInvestment float64 `json:"initialInvestment" example:"1000"`
 
 	// MonthlyContribution is the starting monthly contribution amount.
 	MonthlyContribution float64 `json:"monthlyContribution" example:"500"`
 
-	// Years is the number of years to simulate (1-50).
-	Years int `json:"years" example:"10"`
-
 	// Portfolio is a list of ETF allocations. If provided, calculates blended returns with range.
+	// The "CASH" symbol allocates to a cash sleeve earning CashInterestRate.
 	Portfolio []PortfolioAllocation `json:"portfolio,omitempty"`
 
+	// CashInterestRate is the annual interest percentage earned by the "CASH" sleeve in every scenario.
+	CashInterestRate *float64 `json:"cashInterestRate,omitempty" example:"3.5"`
+
 	// IndexSymbol is the market index symbol (e.g., "SPY", "QQQ"). If provided, returns range projections.
 	// Ignored if Portfolio is provided.
 	IndexSymbol *string `json:"indexSymbol,omitempty" example:"SPY"`
@@ -44,15 +46,91 @@ type SimulateByYearsRequest struct {
 
 	// ContributionGrowthRate is the annual percentage increase in contributions (default: 0).
 	ContributionGrowthRate *float64 `json:"contributionGrowthRate,omitempty" example:"3.0"`
+
+	// TargetAmount is an optional goal. If provided, the summary includes the probability of reaching it.
+	TargetAmount *float64 `json:"targetAmount,omitempty" example:"250000"`
+
+	// ProbabilityMethod selects how the target probability is estimated: "bootstrap" (default)
+	// resamples historical months, "historical" replays every historical window of the same length.
+	ProbabilityMethod *string `json:"probabilityMethod,omitempty" example:"bootstrap"`
+
+	// Granularity aggregates projections into "monthly" (default), "quarterly" or "yearly" periods.
+	// Aggregated periods are returned in place of the monthly projections.
+	Granularity *string `json:"granularity,omitempty" example:"yearly"`
+
+	// Fields restricts the values returned for each period (e.g., ["endValue", "contributions"]).
+	// Valid fields: contributions, growth, endValue, totalContributed, monthlyContribution,
+	// pessimisticValue, optimisticValue, holdings, shares, cash, feesPaid, costBasis, unrealizedGain,
+	// liquidationTax, accounts, withdrawals, externalIncome, benchmarks. Defaults to all fields.
+	Fields []string `json:"fields,omitempty" example:"endValue,contributions"`
+
+	// ValueMilestones are portfolio values whose first reaching date is reported in the summary.
+	// Defaults to 10k, 50k, 100k, 250k, 500k and 1M above the initial investment.
+	ValueMilestones []float64 `json:"valueMilestones,omitempty" example:"50000,100000"`
+
+	// MilestoneIntervalYears is the spacing of contribution milestones in years (default: 5).
+	MilestoneIntervalYears *int `json:"milestoneIntervalYears,omitempty" example:"5"`
+
+	// ShareMode buys "whole" or "fractional" shares at each ETF's price instead of investing
+	// every contribution exactly. Leftover cash is carried forward uninvested. Prices grow at
+	// each ETF's own rate, so holdings drift and are rebalanced through contributions only.
+	// Requires IndexSymbol or Portfolio.
+	ShareMode *string `json:"shareMode,omitempty" example:"whole"`
+
+	// SharePrices overrides the starting price per symbol (defaults to the last close).
+	SharePrices map[string]float64 `json:"sharePrices,omitempty"`
+
+	// Commission is the broker fee charged on each purchase of an ETF. If provided, the summary
+	// includes the fees paid and compares investing every 1, 2, 3, 6 and 12 months.
+	Commission *CommissionSchedule `json:"commission,omitempty"`
+
+	// InvestEveryMonths invests contributions every N months (1-12, default: 1).
+	// Contributions wait as uninvested cash until the next purchase.
+	InvestEveryMonths *int `json:"investEveryMonths,omitempty" example:"3"`
+
+	// CostBasisMethod selects which lots are sold first: "fifo" (default) or "average" cost.
+	CostBasisMethod *string `json:"costBasisMethod,omitempty" example:"fifo"`
+
+	// CapitalGainsTaxRate is the tax percentage on realized gains. If provided, projections include
+	// the tax that would be owed if the portfolio were liquidated that month.
+	CapitalGainsTaxRate *float64 `json:"capitalGainsTaxRate,omitempty" example:"30"`
+
+	// Accounts splits contributions between accounts with contribution caps, filled in the
+	// order listed. Contributions above an account's caps overflow into the next account.
+	// An uncapped "taxable" account is added if every account has a cap.
+	Accounts []Account `json:"accounts,omitempty"`
+
+	// Salary derives contributions from a salary, a contribution percentage and an employer
+	// match. Replaces MonthlyContribution and ContributionGrowthRate.
+	Salary *SalaryPlan `json:"salary,omitempty"`
+
+	// BirthYear and BirthMonth (default: 1) label projections and milestones with age.
+	BirthYear  *int `json:"birthYear,omitempty" example:"1985"`
+	BirthMonth *int `json:"birthMonth,omitempty" example:"4"`
+
+	// RetirementAge stops contributions from the month this age is reached. Requires BirthYear.
+	RetirementAge *int `json:"retirementAge,omitempty" example:"60"`
+
+	// Decumulation withdraws retirement spending, net of external income streams, from the
+	// portfolio once RetirementAge is reached.
+	Decumulation *DecumulationPlan `json:"decumulation,omitempty"`
+
+	// Benchmarks adds baselines holding the same contributions as cash, in a savings account
+	// and indexed to inflation. The summary reports the excess value of the plan over each.
+	Benchmarks *BenchmarkOptions `json:"benchmarks,omitempty"`
+}
+
+// SimulateByYearsRequest is the input for simulating by number of years.
+type SimulateByYearsRequest struct {
+	SimulationInputs
+
+	// Years is the number of years to simulate (1-50).
+	Years int `json:"years" example:"10"`
 }
 
 // SimulateByTargetRequest is the input for simulating until a target date.
 type SimulateByTargetRequest struct {
-	// InitialInvestment is the starting amount.
-	InitialInvestment float64 `json:"initialInvestment" example:"1000"`
-
-	// MonthlyContribution is the starting monthly contribution amount.
-	MonthlyContribution float64 `json:"monthlyContribution" example:"500"`
+	SimulationInputs
 
 	// TargetYear is the target year (e.g., 2035).
 	TargetYear int `json:"targetYear" example:"2035"`
@@ -60,18 +138,9 @@ type SimulateByTargetRequest struct {
 	// TargetMonth is the target month (1-12). Defaults to 12 (December).
 	TargetMonth *int `json:"targetMonth,omitempty" example:"6"`
 
-	// Portfolio is a list of ETF allocations. If provided, calculates blended returns with range.
-	Portfolio []PortfolioAllocation `json:"portfolio,omitempty"`
-
-	// IndexSymbol is the market index symbol (e.g., "SPY", "QQQ"). If provided, returns range projections.
-	// Ignored if Portfolio is provided.
-	IndexSymbol *string `json:"indexSymbol,omitempty" example:"SPY"`
-
-	// AnnualReturnRate is the expected annual return percentage (default: 7.0). Ignored if IndexSymbol or Portfolio is provided.
-	AnnualReturnRate *float64 `json:"annualReturnRate,omitempty" example:"7.0"`
-
-	// ContributionGrowthRate is the annual percentage increase in contributions (default: 0).
-	ContributionGrowthRate *float64 `json:"contributionGrowthRate,omitempty" example:"3.0"`
+	// TargetAge sets the target date to the birthday month of this age, replacing
+	// TargetYear and TargetMonth. Requires BirthYear.
+	TargetAge *int `json:"targetAge,omitempty" example:"60"`
 }
 
 // --- Response Types ---
@@ -84,9 +153,56 @@ type MonthProjection struct {
 	TotalContributed    float64 `json:"totalContributed" example:"4000"`
 	PortfolioValue      float64 `json:"portfolioValue" example:"4150.25"`
 
+	// Age at the end of the month (only present when BirthYear is provided)
+	Age *int `json:"age,omitempty" example:"40"`
+
+	// CostBasis is the purchase cost of the invested holdings, commissions included.
+	// UnrealizedGain is their value minus that cost (uninvested cash excluded).
+	// Only present when CostBasisMethod or CapitalGainsTaxRate is provided.
+	CostBasis      *float64 `json:"costBasis,omitempty" example:"3995.00"`
+	UnrealizedGain *float64 `json:"unrealizedGain,omitempty" example:"155.25"`
+
+	// Salary-based contributions (only present when Salary is provided). MonthlyContribution is
+	// their sum; EmployerValue is the value of employer money, UnvestedValue the part not vested yet.
+	Salary               *float64 `json:"salary,omitempty" example:"61800.00"`
+	EmployeeContribution *float64 `json:"employeeContribution,omitempty" example:"412.00"`
+	EmployerContribution *float64 `json:"employerContribution,omitempty" example:"154.50"`
+	EmployerValue        *float64 `json:"employerValue,omitempty" example:"3820.15"`
+	UnvestedValue        *float64 `json:"unvestedValue,omitempty" example:"1910.08"`
+
+	// Retirement spending and how it is funded (only present in retirement when Decumulation is provided).
+	// Withdrawal is the amount taken from the portfolio and Shortfall the spending left uncovered.
+	// TotalWithdrawn is present in every month.
+	Spending       *float64 `json:"spending,omitempty" example:"3450.00"`
+	ExternalIncome *float64 `json:"externalIncome,omitempty" example:"1380.00"`
+	Withdrawal     *float64 `json:"withdrawal,omitempty" example:"2070.00"`
+	Shortfall      *float64 `json:"shortfall,omitempty" example:"0"`
+	TotalWithdrawn *float64 `json:"totalWithdrawn,omitempty" example:"24840.00"`
+
+	// Accounts splits PortfolioValue between accounts (only present when Accounts is provided)
+	Accounts []AccountValue `json:"accounts,omitempty"`
+
+	// LiquidationTax is the tax owed if everything were sold this month (only present when CapitalGainsTaxRate is provided)
+	LiquidationTax *float64 `json:"liquidationTax,omitempty" example:"46.58"`
+
 	// Range values (only present when IndexSymbol is provided)
 	PessimisticValue *float64 `json:"pessimisticValue,omitempty" example:"3950.00"`
 	OptimisticValue  *float64 `json:"optimisticValue,omitempty" example:"4400.00"`
+
+	// Holdings attributes PortfolioValue to each ETF (only present when Portfolio or ShareMode is provided)
+	Holdings []SymbolValue `json:"holdings,omitempty"`
+
+	// Share positions (only present when ShareMode is provided)
+	Shares []ShareHolding `json:"shares,omitempty"`
+
+	// Cash is the uninvested cash (only present when ShareMode or InvestEveryMonths is provided)
+	Cash *float64 `json:"cash,omitempty" example:"112.40"`
+
+	// FeesPaid is the cumulative commission paid (only present when Commission is provided)
+	FeesPaid *float64 `json:"feesPaid,omitempty" example:"24.00"`
+
+	// Benchmarks are the baseline values (only present when Benchmarks is provided)
+	Benchmarks *BenchmarkValues `json:"benchmarks,omitempty"`
 }
 
 // ContributionMilestone shows the monthly contribution at key years.
@@ -94,14 +210,24 @@ type ContributionMilestone struct {
 	Year                int     `json:"year" example:"2030"`
 	YearsFromNow        int     `json:"yearsFromNow" example:"5"`
 	MonthlyContribution float64 `json:"monthlyContribution" example:"608.33"`
+	Age                 *int    `json:"age,omitempty" example:"45"`
 }
 
-// PortfolioBreakdown shows the allocation and expected return for each ETF.
+// PortfolioBreakdown shows the allocation, expected return and final (median) value of each ETF.
 type PortfolioBreakdown struct {
 	Symbol       string  `json:"symbol" example:"SPY"`
 	Name         string  `json:"name" example:"S&P 500"`
+	AssetClass   string  `json:"assetClass" example:"equity"`
 	Weight       float64 `json:"weight" example:"60"`
 	MedianReturn float64 `json:"medianReturn" example:"8.7"`
+
+	// Final value attributed to this ETF, split into contributions and the growth it generated
+	FinalValue       float64 `json:"finalValue" example:"58210.40"`
+	TotalContributed float64 `json:"totalContributed" example:"36600"`
+	TotalGain        float64 `json:"totalGain" example:"21610.40"`
+
+	// ValueShare is the percentage of the final value attributed to this ETF.
+	ValueShare float64 `json:"valueShare" example:"56.7"`
 }
 
 // SimulateSummary contains the final simulation results.
@@ -117,6 +243,9 @@ type SimulateSummary struct {
 	// ContributionMilestones shows how contributions grow over time.
 	ContributionMilestones []ContributionMilestone `json:"contributionMilestones"`
 
+	// ValueMilestones shows when the portfolio first reaches each milestone value.
+	ValueMilestones []ValueMilestone `json:"valueMilestones"`
+
 	// Range values (only present when IndexSymbol or Portfolio is provided)
 	HasRange           bool     `json:"hasRange"`
 	PessimisticValue   *float64 `json:"pessimisticValue,omitempty" example:"85000.00"`
@@ -129,19 +258,60 @@ type SimulateSummary struct {
 	// Portfolio breakdown (only present when Portfolio is provided)
 	Portfolio           []PortfolioBreakdown `json:"portfolio,omitempty"`
 	BlendedMedianReturn *float64             `json:"blendedMedianReturn,omitempty" example:"9.2"`
+
+	// TargetProbability (only present when TargetAmount is provided)
+	TargetProbability *TargetProbability `json:"targetProbability,omitempty"`
+
+	// Explanation breaks down where the final (median) value comes from.
+	Explanation *ResultExplanation `json:"explanation"`
+
+	// Cost basis of the final (median) holdings and their unrealized gain
+	// (only present when CostBasisMethod or CapitalGainsTaxRate is provided)
+	CostBasis      *float64 `json:"costBasis,omitempty" example:"60950.00"`
+	UnrealizedGain *float64 `json:"unrealizedGain,omitempty" example:"41651.08"`
+
+	// Liquidation results (only present when CapitalGainsTaxRate is provided)
+	LiquidationTax *float64 `json:"liquidationTax,omitempty" example:"12495.32"`
+	AfterTaxValue  *float64 `json:"afterTaxValue,omitempty" example:"90105.76"`
+
+	// Accounts summarizes each account (only present when Accounts is provided)
+	Accounts []AccountSummary `json:"accounts,omitempty"`
+
+	// Age at the end of the simulation (only present when BirthYear is provided)
+	FinalAge *int `json:"finalAge,omitempty" example:"50"`
+
+	// Retirement describes when contributions stop (only present when RetirementAge is reached)
+	Retirement *RetirementSummary `json:"retirement,omitempty"`
+
+	// Decumulation compares portfolio draws with external income (only present when Decumulation is provided)
+	Decumulation *DecumulationSummary `json:"decumulation,omitempty"`
+
+	// Salary summarizes salary-based contributions (only present when Salary is provided)
+	Salary *SalarySummary `json:"salary,omitempty"`
+
+	// Commission results (only present when Commission is provided)
+	TotalFees         *float64           `json:"totalFees,omitempty" example:"240.00"`
+	FrequencyAnalysis *FrequencyAnalysis `json:"frequencyAnalysis,omitempty"`
+
+	// Benchmarks compares the final value with each baseline (only present when Benchmarks is provided)
+	Benchmarks []BenchmarkSummary `json:"benchmarks,omitempty"`
 }
 
 // SimulateByYearsResponse is the output for years-based simulation.
+// Projections is replaced by Periods when a Granularity or Fields selection is requested.
 type SimulateByYearsResponse struct {
 	Inputs      SimulateByYearsRequest `json:"inputs"`
-	Projections []MonthProjection      `json:"projections"`
+	Projections []MonthProjection      `json:"projections,omitempty"`
+	Periods     []PeriodProjection     `json:"periods,omitempty"`
 	Summary     SimulateSummary        `json:"summary"`
 }
 
 // SimulateByTargetResponse is the output for target-date simulation.
+// Projections is replaced by Periods when a Granularity or Fields selection is requested.
 type SimulateByTargetResponse struct {
 	Inputs      SimulateByTargetRequest `json:"inputs"`
-	Projections []MonthProjection       `json:"projections"`
+	Projections []MonthProjection       `json:"projections,omitempty"`
+	Periods     []PeriodProjection      `json:"periods,omitempty"`
 	Summary     SimulateSummary         `json:"summary"`
 }
 
@@ -155,8 +325,12 @@ type SimulateByTargetResponse struct {
 //	@Accept			json
 //	@Produce		json
 //	@Param			request	body		SimulateByYearsRequest	true	"Simulation parameters"
+//	@Param			If-None-Match	header		string	false	"ETag of a previous identical request"
 //	@Success		200		{object}	SimulateByYearsResponse
+//	@Header			200		{string}	ETag	"Validator for If-None-Match"
+//	@Success		304		"Not Modified"
 //	@Failure		400		{object}	ErrorResponse
+//	@Failure		500		{object}	ErrorResponse
 //	@Router			/api/v1/simulate/years [post]
 func (h *Handler) handleSimulateByYears(w http.ResponseWriter, r *http.Request) {
 	var req SimulateByYearsRequest
@@ -165,136 +339,32 @@ func (h *Handler) handleSimulateByYears(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	// Validate inputs
-	if req.InitialInvestment < 0 {
-		respondError(w, http.StatusBadRequest, "initialInvestment must be >= 0")
-		return
-	}
-	if req.MonthlyContribution < 0 {
-		respondError(w, http.StatusBadRequest, "monthlyContribution must be >= 0")
-		return
-	}
-	if req.Years < 1 || req.Years > 50 {
-		respondError(w, http.StatusBadRequest, "years must be between 1 and 50")
-		return
-	}
-
-	// Determine return rates: Portfolio > IndexSymbol > AnnualReturnRate
-	var indexInfo *indexReturnRates
-	var portfolioBreakdown []PortfolioBreakdown
-	var blendedMedian *float64
-
-	if len(req.Portfolio) > 0 {
-		// Portfolio takes precedence
-		result, err := h.calculatePortfolioRates(req.Portfolio)
-		if err != nil {
-			respondError(w, http.StatusBadRequest, err.Error())
-			return
-		}
-		indexInfo = &result.rates
-		portfolioBreakdown = result.breakdown
-		median := round1(result.rates.median)
-		blendedMedian = &median
-	} else if req.IndexSymbol != nil && *req.IndexSymbol != "" {
-		// Single index
-		info, ok := h.indexService.GetIndex(*req.IndexSymbol)
-		if !ok {
-			respondError(w, http.StatusBadRequest, "unknown index symbol: "+*req.IndexSymbol)
-			return
-		}
-		indexInfo = &indexReturnRates{
-			median:      info.MedianReturn,
-			pessimistic: info.PessimisticReturn,
-			optimistic:  info.OptimisticReturn,
-		}
-	}
-
-	// Apply defaults
-	var annualRate float64
-	if indexInfo != nil {
-		annualRate = indexInfo.median
-	} else {
-		annualRate = applyDefault(req.AnnualReturnRate, 7.0)
-	}
-	contributionGrowth := applyDefault(req.ContributionGrowthRate, 0.0)
-
-	req.AnnualReturnRate = &annualRate
-	req.ContributionGrowthRate = &contributionGrowth
-
-	// Validate rates
-	if contributionGrowth < 0 || contributionGrowth > 20 {
-		respondError(w, http.StatusBadRequest, "contributionGrowthRate must be between 0 and 20")
-		return
-	}
-
-	// Calculate dates
-	now := time.Now()
-	startYear := now.Year()
-	startMonth := int(now.Month())
-	totalMonths := req.Years * 12
-
-	endMonth := startMonth
-	endYear := startYear + req.Years
-
-	// Run simulation(s)
-	var projections []MonthProjection
-	var summary SimulateSummary
-
-	if indexInfo != nil {
-		// Run all three simulations for range
-		projections, summary = simulateWithRange(
-			req.InitialInvestment,
-			req.MonthlyContribution,
-			startYear, startMonth,
-			totalMonths,
-			indexInfo,
-			contributionGrowth,
-			endYear, endMonth,
+	respondCached(h, w, r, cacheEndpointYears, &req, h.prepareYears, func(resp *SimulateByYearsResponse) {
+		slog.Debug("simulation by years completed",
+			slog.Float64("initial", resp.Inputs.InitialInvestment),
+			slog.Float64("monthly", resp.Inputs.MonthlyContribution),
+			slog.Int("years", resp.Inputs.Years),
+			slog.Float64("contribution_growth", *resp.Inputs.ContributionGrowthRate),
+			slog.Float64("final_value", resp.Summary.FinalValue),
+			slog.Bool("has_range", resp.Summary.HasRange),
 		)
-		// Add portfolio info if applicable
-		if portfolioBreakdown != nil {
-			summary.Portfolio = portfolioBreakdown
-			summary.BlendedMedianReturn = blendedMedian
-		}
-	} else {
-		// Single simulation
-		projections = simulateMonthly(
-			req.InitialInvestment,
-			req.MonthlyContribution,
-			startYear, startMonth,
-			totalMonths,
-			annualRate,
-			contributionGrowth,
-		)
-		summary = buildSummary(projections, totalMonths, endYear, endMonth, startYear)
-	}
-
-	slog.Debug("simulation by years completed",
-		slog.Float64("initial", req.InitialInvestment),
-		slog.Float64("monthly", req.MonthlyContribution),
-		slog.Int("years", req.Years),
-		slog.Float64("contribution_growth", contributionGrowth),
-		slog.Float64("final_value", summary.FinalValue),
-		slog.Bool("has_range", summary.HasRange),
-	)
-
-	respondJSON(w, http.StatusOK, SimulateByYearsResponse{
-		Inputs:      req,
-		Projections: projections,
-		Summary:     summary,
 	})
 }
 
 // handleSimulateByTarget runs a simulation until a target date.
 //
 //	@Summary		Simulate by target date
-//	@Description	Calculates projected portfolio value until a specific month and year
+//	@Description	Calculates projected portfolio value until a specific month and year, or a target age
 //	@Tags			simulation
 //	@Accept			json
 //	@Produce		json
 //	@Param			request	body		SimulateByTargetRequest	true	"Simulation parameters"
+//	@Param			If-None-Match	header		string	false	"ETag of a previous identical request"
 //	@Success		200		{object}	SimulateByTargetResponse
+//	@Header			200		{string}	ETag	"Validator for If-None-Match"
+//	@Success		304		"Not Modified"
 //	@Failure		400		{object}	ErrorResponse
+//	@Failure		500		{object}	ErrorResponse
 //	@Router			/api/v1/simulate/target [post]
 func (h *Handler) handleSimulateByTarget(w http.ResponseWriter, r *http.Request) {
 	var req SimulateByTargetRequest
@@ -303,14 +373,92 @@ func (h *Handler) handleSimulateByTarget(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	// Validate inputs
-	if req.InitialInvestment < 0 {
-		respondError(w, http.StatusBadRequest, "initialInvestment must be >= 0")
-		return
+	respondCached(h, w, r, cacheEndpointTarget, &req, h.prepareTarget, func(resp *SimulateByTargetResponse) {
+		slog.Debug("simulation by target completed",
+			slog.Float64("initial", resp.Inputs.InitialInvestment),
+			slog.Float64("monthly", resp.Inputs.MonthlyContribution),
+			slog.String("target", resp.Summary.TargetDate),
+			slog.Float64("contribution_growth", *resp.Inputs.ContributionGrowthRate),
+			slog.Float64("final_value", resp.Summary.FinalValue),
+			slog.Bool("has_range", resp.Summary.HasRange),
+		)
+	})
+}
+
+// --- Simulation Modes ---
+
+// prepareYears validates a years-based request and prepares its simulation starting at now.
+func (h *Handler) prepareYears(req *SimulateByYearsRequest, now time.Time) (func(context.Context) (*SimulateByYearsResponse, error), error) {
+	plan, err := h.yearsPlan(req, now)
+	if errors.Check(err) {
+		return nil, err
 	}
-	if req.MonthlyContribution < 0 {
-		respondError(w, http.StatusBadRequest, "monthlyContribution must be >= 0")
-		return
+
+	return func(ctx context.Context) (*SimulateByYearsResponse, error) {
+		result, err := h.runPlan(ctx, plan)
+		if errors.Check(err) {
+			return nil, err
+		}
+
+		return &SimulateByYearsResponse{
+			Inputs:      *req,
+			Projections: result.projections,
+			Periods:     result.periods,
+			Summary:     result.summary,
+		}, nil
+	}, nil
+}
+
+// prepareTarget validates a target-date request and prepares its simulation starting at now.
+func (h *Handler) prepareTarget(req *SimulateByTargetRequest, now time.Time) (func(context.Context) (*SimulateByTargetResponse, error), error) {
+	plan, err := h.targetPlan(req, now)
+	if errors.Check(err) {
+		return nil, err
+	}
+
+	return func(ctx context.Context) (*SimulateByTargetResponse, error) {
+		result, err := h.runPlan(ctx, plan)
+		if errors.Check(err) {
+			return nil, err
+		}
+
+		return &SimulateByTargetResponse{
+			Inputs:      *req,
+			Projections: result.projections,
+			Periods:     result.periods,
+			Summary:     result.summary,
+		}, nil
+	}, nil
+}
+
+// yearsPlan validates a years-based request and builds its plan starting at now.
+func (h *Handler) yearsPlan(req *SimulateByYearsRequest, now time.Time) (*simulationPlan, error) {
+	if req.Years < 1 || req.Years > 50 {
+		return nil, errors.New("years must be between 1 and 50")
+	}
+
+	// Calculate dates
+	startYear := now.Year()
+	startMonth := int(now.Month())
+	totalMonths := req.Years * 12
+
+	endMonth := startMonth
+	endYear := startYear + req.Years
+
+	return h.newPlan(&req.SimulationInputs, startYear, startMonth, totalMonths, endYear, endMonth)
+}
+
+// targetPlan validates a target-date request and builds its plan starting at now.
+// The resolved target date is written back to req.
+func (h *Handler) targetPlan(req *SimulateByTargetRequest, now time.Time) (*simulationPlan, error) {
+	// A target age replaces the target date
+	if req.TargetAge != nil {
+		year, month, err := targetAgeDate(&req.SimulationInputs, *req.TargetAge, now.Year())
+		if errors.Check(err) {
+			return nil, err
+		}
+		req.TargetYear = year
+		req.TargetMonth = &month
 	}
 
 	// Default target month to December
@@ -321,188 +469,393 @@ func (h *Handler) handleSimulateByTarget(w http.ResponseWriter, r *http.Request)
 	req.TargetMonth = &endMonth
 
 	if endMonth < 1 || endMonth > 12 {
-		respondError(w, http.StatusBadRequest, "targetMonth must be between 1 and 12")
-		return
+		return nil, errors.New("targetMonth must be between 1 and 12")
 	}
 
 	// Validate target date is in the future
-	now := time.Now()
 	startYear := now.Year()
 	startMonth := int(now.Month())
 
 	if req.TargetYear < startYear || (req.TargetYear == startYear && endMonth <= startMonth) {
-		respondError(w, http.StatusBadRequest, "target date must be in the future")
-		return
+		return nil, errors.New("target date must be in the future")
 	}
 
 	// Calculate total months
 	totalMonths := (req.TargetYear-startYear)*12 + (endMonth - startMonth)
 	if totalMonths < 1 {
-		respondError(w, http.StatusBadRequest, "simulation period must be at least 1 month")
-		return
+		return nil, errors.New("simulation period must be at least 1 month")
 	}
 	if totalMonths > 600 {
-		respondError(w, http.StatusBadRequest, "simulation period cannot exceed 50 years")
-		return
+		return nil, errors.New("simulation period cannot exceed 50 years")
 	}
 
-	// Determine return rates: Portfolio > IndexSymbol > AnnualReturnRate
-	var indexInfo *indexReturnRates
-	var portfolioBreakdown []PortfolioBreakdown
-	var blendedMedian *float64
+	return h.newPlan(&req.SimulationInputs, startYear, startMonth, totalMonths, req.TargetYear, endMonth)
+}
+
+// --- Shared Logic ---
+
+// simulationPlan holds validated, defaulted inputs for a single simulation run.
+type simulationPlan struct {
+	initial            float64
+	monthlyBase        float64
+	contributionGrowth float64
+	annualRate         float64
+
+	startYear   int
+	startMonth  int
+	totalMonths int
+	endYear     int
+	endMonth    int
+
+	// rates is nil for fixed-rate simulations.
+	rates *indexReturnRates
+
+	// portfolio is nil unless a Portfolio was provided.
+	portfolio *portfolioResult
+
+	// allocations lists the symbols backing the return rates (a single 100% entry for an index).
+	allocations []PortfolioAllocation
+
+	// cashRate is the interest rate of the cash sleeve (nil if not provided).
+	cashRate *float64
+
+	targetAmount      *float64
+	probabilityMethod string
+
+	// granularity and fields shape the returned projections; aggregate is false for raw months.
+	granularity string
+	fields      map[string]bool
+	aggregate   bool
+
+	valueMilestones   []float64
+	milestoneInterval int
+
+	// costBasisMethod decides which lots are sold first; taxRate is nil unless provided.
+	// Cost basis is only reported when either option is provided.
+	costBasisMethod string
+	taxRate         *float64
+	reportCostBasis bool
+
+	// birthYear is zero unless a birth date was provided; retirementAge is nil if contributions never stop.
+	birthYear     int
+	birthMonth    int
+	retirementAge *int
 
-	if len(req.Portfolio) > 0 {
+	// decumulation is nil unless retirement spending is withdrawn.
+	decumulation *decumulationConfig
+
+	// goalWithdrawals maps month indices to one-off amounts withdrawn for household goals.
+	goalWithdrawals map[int]float64
+
+	// salary is nil unless contributions are derived from a salary.
+	salary *SalaryPlan
+
+	// extraContribution is added to every monthly contribution (used to solve for catch-up amounts).
+	extraContribution float64
+
+	// accounts lists the accounts in fill order (nil for a single account).
+	accounts []Account
+
+	// benchmarks is nil unless baseline comparisons were requested.
+	benchmarks *benchmarkConfig
+
+	// shares is nil unless a ShareMode was requested.
+	shares *shareConfig
+
+	// commission is nil for commission-free purchases. Each purchase is split into
+	// one trade per tradeWeights entry, and purchases happen every investEvery months.
+	commission   *CommissionSchedule
+	tradeWeights []float64
+	investEvery  int
+
+	// observer is nil unless results are streamed as they are computed.
+	observer *planObserver
+}
+
+// simulationResult holds everything a simulation run returns.
+type simulationResult struct {
+	projections []MonthProjection
+	periods     []PeriodProjection
+	summary     SimulateSummary
+}
+
+// prepareFunc validates a request, writing its defaults back to req, and returns the run that
+// simulates it. Requests are prepared before anything is simulated, so invalid requests fail
+// early and prepared requests can be used as cache keys.
+type prepareFunc[Req, Resp any] func(req *Req, now time.Time) (func(ctx context.Context) (Resp, error), error)
+
+// newPlan validates the shared inputs, resolves the return source and applies defaults.
+// Defaults are written back to in so they are echoed in the response inputs.
+func (h *Handler) newPlan(in *SimulationInputs, startYear, startMonth, totalMonths, endYear, endMonth int) (*simulationPlan, error) {
+	// Validate inputs
+	if in.InitialInvestment < 0 {
+		return nil, errors.New("initialInvestment must be >= 0")
+	}
+	if in.MonthlyContribution < 0 {
+		return nil, errors.New("monthlyContribution must be >= 0")
+	}
+
+	plan := &simulationPlan{
+		initial:     in.InitialInvestment,
+		monthlyBase: in.MonthlyContribution,
+		startYear:   startYear,
+		startMonth:  startMonth,
+		totalMonths: totalMonths,
+		endYear:     endYear,
+		endMonth:    endMonth,
+	}
+
+	// Interest on the cash sleeve
+	if err := applyCashRate(plan, in.CashInterestRate); errors.Check(err) {
+		return nil, err
+	}
+
+	// Determine return rates: Portfolio > IndexSymbol > AnnualReturnRate
+	if len(in.Portfolio) > 0 {
 		// Portfolio takes precedence
-		result, err := h.calculatePortfolioRates(req.Portfolio)
-		if err != nil {
-			respondError(w, http.StatusBadRequest, err.Error())
-			return
+		result, err := h.calculatePortfolioRates(in.Portfolio, plan.cashRate)
+		if errors.Check(err) {
+			return nil, err
 		}
-		indexInfo = &result.rates
-		portfolioBreakdown = result.breakdown
-		median := round1(result.rates.median)
-		blendedMedian = &median
-	} else if req.IndexSymbol != nil && *req.IndexSymbol != "" {
+		plan.rates = &result.rates
+		plan.portfolio = result
+		plan.allocations = in.Portfolio
+	} else if in.IndexSymbol != nil && *in.IndexSymbol != "" {
 		// Single index
-		info, ok := h.indexService.GetIndex(*req.IndexSymbol)
-		if !ok {
-			respondError(w, http.StatusBadRequest, "unknown index symbol: "+*req.IndexSymbol)
-			return
+		info, err := h.lookupIndex(*in.IndexSymbol, plan.cashRate)
+		if errors.Check(err) {
+			return nil, err
 		}
-		indexInfo = &indexReturnRates{
+		plan.rates = &indexReturnRates{
 			median:      info.MedianReturn,
 			pessimistic: info.PessimisticReturn,
 			optimistic:  info.OptimisticReturn,
 		}
+		plan.allocations = []PortfolioAllocation{{Symbol: info.Symbol, Weight: 100}}
 	}
 
 	// Apply defaults
-	var annualRate float64
-	if indexInfo != nil {
-		annualRate = indexInfo.median
+	if plan.rates != nil {
+		plan.annualRate = plan.rates.median
 	} else {
-		annualRate = applyDefault(req.AnnualReturnRate, 7.0)
+		plan.annualRate = applyDefault(in.AnnualReturnRate, 7.0)
 	}
-	contributionGrowth := applyDefault(req.ContributionGrowthRate, 0.0)
+	plan.contributionGrowth = applyDefault(in.ContributionGrowthRate, 0.0)
 
-	req.AnnualReturnRate = &annualRate
-	req.ContributionGrowthRate = &contributionGrowth
+	in.AnnualReturnRate = &plan.annualRate
+	in.ContributionGrowthRate = &plan.contributionGrowth
 
 	// Validate rates
-	if contributionGrowth < 0 || contributionGrowth > 20 {
-		respondError(w, http.StatusBadRequest, "contributionGrowthRate must be between 0 and 20")
-		return
+	if plan.contributionGrowth < 0 || plan.contributionGrowth > 20 {
+		return nil, errors.New("contributionGrowthRate must be between 0 and 20")
+	}
+
+	// Target probability options
+	if in.TargetAmount != nil {
+		if *in.TargetAmount <= 0 {
+			return nil, errors.New("targetAmount must be > 0")
+		}
+		plan.targetAmount = in.TargetAmount
+
+		plan.probabilityMethod = probabilityMethodBootstrap
+		if in.ProbabilityMethod != nil && *in.ProbabilityMethod != "" {
+			plan.probabilityMethod = *in.ProbabilityMethod
+		}
+		if plan.probabilityMethod != probabilityMethodBootstrap && plan.probabilityMethod != probabilityMethodHistorical {
+			return nil, errors.New("probabilityMethod must be \"bootstrap\" or \"historical\"")
+		}
+		in.ProbabilityMethod = &plan.probabilityMethod
+
+		// Too short a history is an invalid request, so it is reported before running. History
+		// that cannot be loaded is left to the run to report.
+		if plan.probabilityMethod == probabilityMethodHistorical && plan.rates != nil && !onlyCash(plan.allocations) {
+			if history, err := h.historicalReturns(plan.allocations, plan.cashRate); !errors.Check(err) {
+				if _, err := historicalWindows(len(history.blended), totalMonths); errors.Check(err) {
+					return nil, err
+				}
+			}
+		}
+	}
+
+	// Projection shaping options
+	if err := applyGranularity(plan, in.Granularity, in.Fields); errors.Check(err) {
+		return nil, err
+	}
+
+	// Milestone options
+	if err := applyMilestones(plan, in.ValueMilestones, in.MilestoneIntervalYears); errors.Check(err) {
+		return nil, err
 	}
+	in.MilestoneIntervalYears = &plan.milestoneInterval
 
-	// Run simulation(s)
+	// Share mode
+	if err := h.applyShareMode(plan, in.ShareMode, in.SharePrices); errors.Check(err) {
+		return nil, err
+	}
+
+	// Commission and purchase frequency
+	if err := applyCommission(plan, in.Commission, in.InvestEveryMonths); errors.Check(err) {
+		return nil, err
+	}
+	in.InvestEveryMonths = &plan.investEvery
+
+	// Cost basis and tax options
+	if err := applyCostBasis(plan, in.CostBasisMethod, in.CapitalGainsTaxRate); errors.Check(err) {
+		return nil, err
+	}
+	if plan.reportCostBasis {
+		in.CostBasisMethod = &plan.costBasisMethod
+	}
+
+	// Age labels and retirement
+	if err := applyAge(plan, in); errors.Check(err) {
+		return nil, err
+	}
+
+	// Retirement withdrawals
+	if err := applyDecumulation(plan, in.Decumulation); errors.Check(err) {
+		return nil, err
+	}
+
+	// Salary-based contributions
+	if err := applySalary(plan, in.Salary); errors.Check(err) {
+		return nil, err
+	}
+
+	// Accounts with contribution caps
+	if err := applyAccounts(plan, in); errors.Check(err) {
+		return nil, err
+	}
+
+	// Baselines outside the market
+	if err := applyBenchmarks(plan, in.Benchmarks); errors.Check(err) {
+		return nil, err
+	}
+
+	return plan, nil
+}
+
+// runPlan runs the deterministic projections and any requested analyses for a plan.
+// The target probability estimate stops early if ctx is cancelled.
+func (h *Handler) runPlan(ctx context.Context, plan *simulationPlan) (*simulationResult, error) {
 	var projections []MonthProjection
 	var summary SimulateSummary
 
-	if indexInfo != nil {
+	if plan.rates != nil {
 		// Run all three simulations for range
-		projections, summary = simulateWithRange(
-			req.InitialInvestment,
-			req.MonthlyContribution,
-			startYear, startMonth,
-			totalMonths,
-			indexInfo,
-			contributionGrowth,
-			req.TargetYear, endMonth,
-		)
+		projections, summary = simulateWithRange(plan)
 		// Add portfolio info if applicable
-		if portfolioBreakdown != nil {
-			summary.Portfolio = portfolioBreakdown
-			summary.BlendedMedianReturn = blendedMedian
+		if plan.portfolio != nil {
+			if plan.shares != nil {
+				summary.Portfolio = shareBreakdown(plan, projections)
+			} else {
+				summary.Portfolio = attributeHoldings(plan, projections)
+			}
+			median := round1(plan.portfolio.rates.median)
+			summary.BlendedMedianReturn = &median
 		}
 	} else {
 		// Single simulation
-		projections = simulateMonthly(
-			req.InitialInvestment,
-			req.MonthlyContribution,
-			startYear, startMonth,
-			totalMonths,
-			annualRate,
-			contributionGrowth,
-		)
-		summary = buildSummary(projections, totalMonths, req.TargetYear, endMonth, startYear)
-	}
-
-	slog.Debug("simulation by target completed",
-		slog.Float64("initial", req.InitialInvestment),
-		slog.Float64("monthly", req.MonthlyContribution),
-		slog.String("target", summary.TargetDate),
-		slog.Float64("contribution_growth", contributionGrowth),
-		slog.Float64("final_value", summary.FinalValue),
-		slog.Bool("has_range", summary.HasRange),
-	)
-
-	respondJSON(w, http.StatusOK, SimulateByTargetResponse{
-		Inputs:      req,
-		Projections: projections,
-		Summary:     summary,
-	})
-}
+		projections = simulateMonthly(plan, scenarioMedian)
+		summary = buildSummary(projections, plan)
+	}
 
-// --- Shared Logic ---
+	// Benchmarks fill in monthly values, so they are added before projections are shaped
+	if plan.benchmarks != nil {
+		summary.Benchmarks = addBenchmarks(plan, projections)
+	}
 
-// applyDefault returns the pointer value or a default.
-func applyDefault(ptr *float64, defaultVal float64) float64 {
-	if ptr != nil {
-		return *ptr
+	// Projections are shaped before the analyses so they can be streamed first
+	result := &simulationResult{}
+	if plan.aggregate {
+		result.periods = selectFields(aggregateProjections(projections, plan.initial, plan.granularity), plan.fields)
+	} else {
+		result.projections = projections
+	}
+	if plan.observer != nil {
+		plan.observer.projections(result.projections, result.periods)
 	}
-	return defaultVal
-}
 
-// simulateMonthly calculates month-by-month portfolio growth with growing contributions.
-func simulateMonthly(
-	initial, monthlyBase float64,
-	startYear, startMonth, totalMonths int,
-	annualRate, contributionGrowth float64,
-) []MonthProjection {
-	// Convert annual rates to monthly factors
-	monthlyReturnRate := math.Pow(1+annualRate/100, 1.0/12.0) - 1
-	monthlyContributionGrowth := math.Pow(1+contributionGrowth/100, 1.0/12.0) - 1
-
-	projections := make([]MonthProjection, 0, totalMonths)
-	balance := initial
-	totalContributed := initial
-	currentContribution := monthlyBase
-
-	currentYear := startYear
-	currentMonth := startMonth
-
-	for i := 0; i < totalMonths; i++ {
-		// Advance to next month
-		currentMonth++
-		if currentMonth > 12 {
-			currentMonth = 1
-			currentYear++
+	summary.ValueMilestones = buildValueMilestones(plan, projections)
+	summary.Explanation = explainResult(plan, projections)
+
+	final := projections[len(projections)-1]
+	summary.CostBasis = final.CostBasis
+	summary.UnrealizedGain = final.UnrealizedGain
+	if final.LiquidationTax != nil {
+		afterTax := round2(final.PortfolioValue - *final.LiquidationTax)
+		summary.LiquidationTax = final.LiquidationTax
+		summary.AfterTaxValue = &afterTax
+	}
+
+	if plan.birthYear != 0 {
+		summary.FinalAge = final.Age
+	}
+	if plan.retirementAge != nil {
+		summary.Retirement = buildRetirementSummary(plan, projections)
+	}
+
+	if plan.decumulation != nil {
+		summary.Decumulation = buildDecumulationSummary(projections)
+	}
+
+	if plan.salary != nil {
+		summary.Salary = buildSalarySummary(plan, projections)
+	}
+
+	if plan.accounts != nil {
+		summary.Accounts = buildAccountSummaries(plan, projections)
+	}
+
+	if plan.commission != nil {
+		summary.TotalFees = final.FeesPaid
+		summary.FrequencyAnalysis = analyzeFrequencies(plan)
+	}
+
+	if plan.targetAmount != nil {
+		probability, err := h.estimateTargetProbability(ctx, plan, projections)
+		if errors.Check(err) {
+			return nil, err
 		}
+		summary.TargetProbability = probability
+	}
 
-		// Apply investment return
-		balance *= (1 + monthlyReturnRate)
+	result.summary = summary
+	return result, nil
+}
 
-		// Add contribution (grows each month)
-		balance += currentContribution
-		totalContributed += currentContribution
+// progressKey is the context key of the function receiving a simulation's progress.
+type progressKey struct{}
 
-		projections = append(projections, MonthProjection{
-			Year:                currentYear,
-			Month:               currentMonth,
-			MonthlyContribution: round2(currentContribution),
-			TotalContributed:    round2(totalContributed),
-			PortfolioValue:      round2(balance),
-		})
+// withProgress returns a copy of ctx whose simulation reports its progress to progress.
+// A nil progress stops reporting, e.g. for the plans of a simulation that reports as a whole.
+func withProgress(ctx context.Context, progress func(completed, total int)) context.Context {
+	return context.WithValue(ctx, progressKey{}, progress)
+}
+
+// reportProgress reports that completed of total steps of a simulation are done, if ctx
+// has a progress function.
+func reportProgress(ctx context.Context, completed, total int) {
+	if progress, _ := ctx.Value(progressKey{}).(func(completed, total int)); progress != nil {
+		progress(completed, total)
+	}
+}
 
-		// Grow contribution for next month
-		currentContribution *= (1 + monthlyContributionGrowth)
+// applyDefault returns the pointer value or a default.
+func applyDefault(ptr *float64, defaultVal float64) float64 {
+	if ptr != nil {
+		return *ptr
 	}
+	return defaultVal
+}
 
-	return projections
+// simulateMonthly calculates month-by-month portfolio growth with growing contributions for a scenario.
+func simulateMonthly(plan *simulationPlan, s scenario) []MonthProjection {
+	return runEngine(plan, plan.scenarioPath(s), true).projections
 }
 
 // buildSummary creates the summary from projections.
-func buildSummary(projections []MonthProjection, totalMonths, endYear, endMonth, startYear int) SimulateSummary {
+func buildSummary(projections []MonthProjection, plan *simulationPlan) SimulateSummary {
 	finalProjection := projections[len(projections)-1]
 	totalContributed := finalProjection.TotalContributed
 	totalGain := finalProjection.PortfolioValue - totalContributed
@@ -512,10 +865,10 @@ func buildSummary(projections []MonthProjection, totalMonths, endYear, endMonth,
 		percentageGain = round1((totalGain / totalContributed) * 100)
 	}
 
-	targetDate := time.Month(endMonth).String() + " " + time.Date(endYear, 1, 1, 0, 0, 0, 0, time.UTC).Format("2006")
+	targetDate := time.Month(plan.endMonth).String() + " " + time.Date(plan.endYear, 1, 1, 0, 0, 0, 0, time.UTC).Format("2006")
 
 	// Build contribution milestones
-	milestones := buildContributionMilestones(projections, startYear)
+	milestones := buildContributionMilestones(projections, plan.startYear, plan.milestoneInterval)
 
 	return SimulateSummary{
 		TargetDate:               targetDate,
@@ -523,22 +876,22 @@ func buildSummary(projections []MonthProjection, totalMonths, endYear, endMonth,
 		TotalContributed:         round2(totalContributed),
 		TotalGain:                round2(totalGain),
 		PercentageGain:           percentageGain,
-		TotalMonths:              totalMonths,
+		TotalMonths:              plan.totalMonths,
 		FinalMonthlyContribution: finalProjection.MonthlyContribution,
 		ContributionMilestones:   milestones,
 	}
 }
 
-// buildContributionMilestones extracts contribution values at key years (now, 5, 10, 15, 20, etc.).
-func buildContributionMilestones(projections []MonthProjection, startYear int) []ContributionMilestone {
+// buildContributionMilestones extracts contribution values every intervalYears (e.g., 5, 10, 15, 20).
+func buildContributionMilestones(projections []MonthProjection, startYear, intervalYears int) []ContributionMilestone {
 	milestones := []ContributionMilestone{}
 
 	// Find milestones at specific intervals
 	milestoneYears := map[int]bool{}
 	totalYears := projections[len(projections)-1].Year - startYear
 
-	// Add milestones at 5-year intervals, plus the final year
-	for y := 5; y <= totalYears; y += 5 {
+	// Add milestones at the requested interval, plus the final year
+	for y := intervalYears; y <= totalYears; y += intervalYears {
 		milestoneYears[startYear+y] = true
 	}
 	// Always include final year
@@ -554,6 +907,7 @@ func buildContributionMilestones(projections []MonthProjection, startYear int) [
 				Year:                p.Year,
 				YearsFromNow:        p.Year - startYear,
 				MonthlyContribution: p.MonthlyContribution,
+				Age:                 p.Age,
 			})
 			addedYears[p.Year] = true
 		}
@@ -566,6 +920,7 @@ func buildContributionMilestones(projections []MonthProjection, startYear int) [
 			Year:                finalProj.Year,
 			YearsFromNow:        finalProj.Year - startYear,
 			MonthlyContribution: finalProj.MonthlyContribution,
+			Age:                 finalProj.Age,
 		})
 	}
 
@@ -593,10 +948,13 @@ type indexReturnRates struct {
 type portfolioResult struct {
 	rates     indexReturnRates
 	breakdown []PortfolioBreakdown
+
+	// symbolRates holds each ETF's own rates, in the same order as breakdown.
+	symbolRates []indexReturnRates
 }
 
 // calculatePortfolioRates calculates weighted average returns for a portfolio.
-func (h *Handler) calculatePortfolioRates(allocations []PortfolioAllocation) (*portfolioResult, error) {
+func (h *Handler) calculatePortfolioRates(allocations []PortfolioAllocation, cashRate *float64) (*portfolioResult, error) {
 	if len(allocations) == 0 {
 		return nil, errors.New("portfolio cannot be empty")
 	}
@@ -616,11 +974,12 @@ func (h *Handler) calculatePortfolioRates(allocations []PortfolioAllocation) (*p
 	// Calculate weighted average rates
 	var medianSum, pessSum, optSum float64
 	breakdown := make([]PortfolioBreakdown, 0, len(allocations))
+	symbolRates := make([]indexReturnRates, 0, len(allocations))
 
 	for _, a := range allocations {
-		info, ok := h.indexService.GetIndex(a.Symbol)
-		if !ok {
-			return nil, errors.New("unknown index symbol: " + a.Symbol)
+		info, err := h.lookupIndex(a.Symbol, cashRate)
+		if errors.Check(err) {
+			return nil, err
 		}
 
 		weight := a.Weight / 100.0 // Convert to decimal
@@ -631,9 +990,15 @@ func (h *Handler) calculatePortfolioRates(allocations []PortfolioAllocation) (*p
 		breakdown = append(breakdown, PortfolioBreakdown{
 			Symbol:       a.Symbol,
 			Name:         info.Name,
+			AssetClass:   info.AssetClass,
 			Weight:       a.Weight,
 			MedianReturn: round1(info.MedianReturn),
 		})
+		symbolRates = append(symbolRates, indexReturnRates{
+			median:      info.MedianReturn,
+			pessimistic: info.PessimisticReturn,
+			optimistic:  info.OptimisticReturn,
+		})
 	}
 
 	return &portfolioResult{
@@ -642,22 +1007,17 @@ func (h *Handler) calculatePortfolioRates(allocations []PortfolioAllocation) (*p
 			pessimistic: pessSum,
 			optimistic:  optSum,
 		},
-		breakdown: breakdown,
+		breakdown:   breakdown,
+		symbolRates: symbolRates,
 	}, nil
 }
 
 // simulateWithRange runs three simulations (pessimistic, median, optimistic) and merges results.
-func simulateWithRange(
-	initial, monthlyBase float64,
-	startYear, startMonth, totalMonths int,
-	rates *indexReturnRates,
-	contributionGrowth float64,
-	endYear, endMonth int,
-) ([]MonthProjection, SimulateSummary) {
+func simulateWithRange(plan *simulationPlan) ([]MonthProjection, SimulateSummary) {
 	// Run all three simulations
-	medianProj := simulateMonthly(initial, monthlyBase, startYear, startMonth, totalMonths, rates.median, contributionGrowth)
-	pessimisticProj := simulateMonthly(initial, monthlyBase, startYear, startMonth, totalMonths, rates.pessimistic, contributionGrowth)
-	optimisticProj := simulateMonthly(initial, monthlyBase, startYear, startMonth, totalMonths, rates.optimistic, contributionGrowth)
+	medianProj := simulateMonthly(plan, scenarioMedian)
+	pessimisticProj := simulateMonthly(plan, scenarioPessimistic)
+	optimisticProj := simulateMonthly(plan, scenarioOptimistic)
 
 	// Merge into single projection list with range values
 	projections := make([]MonthProjection, len(medianProj))
@@ -665,19 +1025,13 @@ func simulateWithRange(
 		pessVal := pessimisticProj[i].PortfolioValue
 		optVal := optimisticProj[i].PortfolioValue
 
-		projections[i] = MonthProjection{
-			Year:                medianProj[i].Year,
-			Month:               medianProj[i].Month,
-			MonthlyContribution: medianProj[i].MonthlyContribution,
-			TotalContributed:    medianProj[i].TotalContributed,
-			PortfolioValue:      medianProj[i].PortfolioValue,
-			PessimisticValue:    &pessVal,
-			OptimisticValue:     &optVal,
-		}
+		projections[i] = medianProj[i]
+		projections[i].PessimisticValue = &pessVal
+		projections[i].OptimisticValue = &optVal
 	}
 
 	// Build summary with range
-	summary := buildSummary(projections, totalMonths, endYear, endMonth, startYear)
+	summary := buildSummary(projections, plan)
 
 	// Add range values to summary
 	finalPess := pessimisticProj[len(pessimisticProj)-1]
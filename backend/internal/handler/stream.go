@@ -0,0 +1,230 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/abdonasmane/etfs-simulator/backend/sdk/errors"
+)
+
+// eventStreamContentType is the media type of Server-Sent Events.
+const eventStreamContentType = "text/event-stream"
+
+// streamWriteTimeout is how long each event may take to write. The server's write timeout
+// covers a whole response, so streams extend their deadline before every event instead.
+const streamWriteTimeout = 10 * time.Second
+
+// Stream events, in the order they are sent.
+const (
+	streamEventInputs     = "inputs"
+	streamEventProjection = "projection"
+	streamEventPeriod     = "period"
+	streamEventProgress   = "progress"
+	streamEventSummary    = "summary"
+	streamEventError      = "error"
+)
+
+// --- Response Types ---
+
+// StreamEvent is one line of an NDJSON stream. Server-Sent Events carry the same event
+// name and data as the "event" and "data" fields.
+//
+// A stream sends the defaulted inputs, then every projection ("projection", or "period" when
+// a Granularity or Fields selection is requested) as soon as the projections are computed,
+// then "progress" while the target probability is estimated, and ends with the "summary".
+// A failure after the stream has started ends it with an "error" event holding an ErrorResponse.
+type StreamEvent struct {
+	Event string `json:"event" example:"projection"`
+	Data  any    `json:"data" swaggertype:"object"`
+}
+
+// StreamProgress reports the progress of the target probability estimate.
+type StreamProgress struct {
+	Completed int     `json:"completed" example:"400"`
+	Total     int     `json:"total" example:"2000"`
+	Percent   float64 `json:"percent" example:"20"`
+}
+
+// --- Handlers ---
+
+// handleSimulateByYearsStream streams a simulation for a specified number of years.
+//
+//	@Summary		Stream simulation by years
+//	@Description	Runs a simulation by years and streams its projections, probability progress and summary as they are computed, as Server-Sent Events when requested with Accept: text/event-stream and as NDJSON otherwise
+//	@Tags			simulation
+//	@Accept			json
+//	@Produce		application/x-ndjson
+//	@Produce		event-stream
+//	@Param			request	body		SimulateByYearsRequest	true	"Simulation parameters"
+//	@Success		200		{object}	StreamEvent
+//	@Failure		400		{object}	ErrorResponse
+//	@Router			/api/v1/simulate/years/stream [post]
+func (h *Handler) handleSimulateByYearsStream(w http.ResponseWriter, r *http.Request) {
+	var req SimulateByYearsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); errors.Check(err) {
+		respondError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	plan, err := h.yearsPlan(&req, time.Now())
+	if errors.Check(err) {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	h.streamPlan(w, r, plan, req)
+}
+
+// handleSimulateByTargetStream streams a simulation until a target date.
+//
+//	@Summary		Stream simulation by target date
+//	@Description	Runs a simulation by target date and streams its projections, probability progress and summary as they are computed, as Server-Sent Events when requested with Accept: text/event-stream and as NDJSON otherwise
+//	@Tags			simulation
+//	@Accept			json
+//	@Produce		application/x-ndjson
+//	@Produce		event-stream
+//	@Param			request	body		SimulateByTargetRequest	true	"Simulation parameters"
+//	@Success		200		{object}	StreamEvent
+//	@Failure		400		{object}	ErrorResponse
+//	@Router			/api/v1/simulate/target/stream [post]
+func (h *Handler) handleSimulateByTargetStream(w http.ResponseWriter, r *http.Request) {
+	var req SimulateByTargetRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); errors.Check(err) {
+		respondError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	plan, err := h.targetPlan(&req, time.Now())
+	if errors.Check(err) {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	h.streamPlan(w, r, plan, req)
+}
+
+// --- Streaming ---
+
+// planObserver receives a plan's results as they are computed.
+type planObserver struct {
+	// projections receives the projections, or the periods when aggregated, before the analyses run.
+	projections func(projections []MonthProjection, periods []PeriodProjection)
+}
+
+// streamPlan runs a validated plan and streams its results. Errors can no longer change the
+// status once the stream has started, so they are sent as an error event. The run stops
+// when the client goes away or an event cannot be written.
+func (h *Handler) streamPlan(w http.ResponseWriter, r *http.Request, plan *simulationPlan, inputs any) {
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+
+	stream := newEventStream(w, r, cancel)
+	stream.send(streamEventInputs, inputs)
+
+	plan.observer = &planObserver{
+		projections: func(projections []MonthProjection, periods []PeriodProjection) {
+			for _, p := range projections {
+				stream.send(streamEventProjection, p)
+			}
+			for _, p := range periods {
+				stream.send(streamEventPeriod, p)
+			}
+		},
+	}
+	ctx = withProgress(ctx, func(completed, total int) {
+		stream.send(streamEventProgress, StreamProgress{
+			Completed: completed,
+			Total:     total,
+			Percent:   round1(float64(completed) / float64(total) * 100),
+		})
+	})
+
+	result, err := h.runPlan(ctx, plan)
+	if errors.Check(ctx.Err()) {
+		slog.Debug("simulation stream cancelled", slog.String("error", ctx.Err().Error()))
+		return
+	}
+	if errors.Check(err) {
+		stream.send(streamEventError, ErrorResponse{Error: err.Error()})
+		return
+	}
+	stream.send(streamEventSummary, result.summary)
+
+	slog.Debug("simulation stream completed",
+		slog.Int("months", plan.totalMonths),
+		slog.Bool("sse", stream.sse),
+		slog.Float64("final_value", result.summary.FinalValue),
+	)
+}
+
+// eventStream writes events as Server-Sent Events or NDJSON, flushing each one.
+type eventStream struct {
+	w          http.ResponseWriter
+	controller *http.ResponseController
+	sse        bool
+	started    bool
+
+	// err is the first write error; once set, events are dropped and stop is called
+	// (e.g., the client went away).
+	err  error
+	stop func()
+}
+
+// newEventStream creates a stream in the format the request accepts. stop is called
+// once an event cannot be written.
+func newEventStream(w http.ResponseWriter, r *http.Request, stop func()) *eventStream {
+	return &eventStream{
+		w:          w,
+		controller: http.NewResponseController(w),
+		sse:        strings.Contains(r.Header.Get("Accept"), eventStreamContentType),
+		stop:       stop,
+	}
+}
+
+// send writes an event, starting the response on the first one.
+func (s *eventStream) send(event string, data any) {
+	if errors.Check(s.err) {
+		return
+	}
+
+	// Writers that do not support deadlines (e.g., in tests) rely on the server's timeout
+	_ = s.controller.SetWriteDeadline(time.Now().Add(streamWriteTimeout))
+
+	if !s.started {
+		s.started = true
+		if s.sse {
+			s.w.Header().Set("Content-Type", eventStreamContentType)
+		} else {
+			s.w.Header().Set("Content-Type", ndjsonContentType)
+		}
+		s.w.Header().Set("Cache-Control", "no-cache")
+		s.w.WriteHeader(http.StatusOK)
+	}
+
+	if s.sse {
+		payload, err := json.Marshal(data)
+		if errors.Check(err) {
+			s.err = errors.Wrap(err, "encoding event")
+		} else {
+			_, s.err = fmt.Fprintf(s.w, "event: %s\ndata: %s\n\n", event, payload)
+		}
+	} else {
+		s.err = json.NewEncoder(s.w).Encode(StreamEvent{Event: event, Data: data})
+	}
+
+	if !errors.Check(s.err) {
+		if err := s.controller.Flush(); !errors.Is(err, http.ErrNotSupported) {
+			s.err = err
+		}
+	}
+
+	if errors.Check(s.err) {
+		slog.Debug("simulation stream stopped", slog.String("error", s.err.Error()))
+		s.stop()
+	}
+}
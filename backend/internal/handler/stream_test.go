@@ -0,0 +1,205 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/abdonasmane/etfs-simulator/backend/sdk/errors"
+)
+
+// TestEventStream tests that events are written as NDJSON by default and as SSE when accepted.
+func TestEventStream(t *testing.T) {
+	tests := []struct {
+		name        string
+		accept      string
+		contentType string
+		want        string
+	}{
+		{"ndjson", "", ndjsonContentType, `{"event":"progress","data":{"completed":1,"total":4,"percent":25}}` + "\n"},
+		{"sse", "text/event-stream", eventStreamContentType, "event: progress\ndata: {\"completed\":1,\"total\":4,\"percent\":25}\n\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("POST", "/api/v1/simulate/years/stream", nil)
+			r.Header.Set("Accept", tt.accept)
+			w := httptest.NewRecorder()
+
+			stream := newEventStream(w, r, func() {})
+			stream.send(streamEventProgress, StreamProgress{Completed: 1, Total: 4, Percent: 25})
+
+			if got := w.Header().Get("Content-Type"); got != tt.contentType {
+				t.Errorf("expected content type %q, got %q", tt.contentType, got)
+			}
+			if got := w.Body.String(); got != tt.want {
+				t.Errorf("expected body %q, got %q", tt.want, got)
+			}
+			if !w.Flushed {
+				t.Error("expected the event to be flushed")
+			}
+		})
+	}
+}
+
+// recordedEvent is an event read back from a recorded stream.
+type recordedEvent struct {
+	Event string          `json:"event"`
+	Data  json.RawMessage `json:"data"`
+}
+
+// readEvents parses a recorded NDJSON or SSE stream.
+func readEvents(t *testing.T, w *httptest.ResponseRecorder) []recordedEvent {
+	t.Helper()
+
+	var events []recordedEvent
+	if w.Header().Get("Content-Type") == eventStreamContentType {
+		for _, block := range strings.Split(strings.TrimSpace(w.Body.String()), "\n\n") {
+			event, data, _ := strings.Cut(block, "\n")
+			events = append(events, recordedEvent{
+				Event: strings.TrimPrefix(event, "event: "),
+				Data:  json.RawMessage(strings.TrimPrefix(data, "data: ")),
+			})
+		}
+		return events
+	}
+
+	decoder := json.NewDecoder(w.Body)
+	for decoder.More() {
+		var event recordedEvent
+		if err := decoder.Decode(&event); errors.Check(err) {
+			t.Fatalf("invalid event: %v", err)
+		}
+		events = append(events, event)
+	}
+	return events
+}
+
+// eventOrder returns the event names of a stream with consecutive repeats collapsed.
+func eventOrder(events []recordedEvent) []string {
+	var order []string
+	for _, e := range events {
+		if len(order) == 0 || order[len(order)-1] != e.Event {
+			order = append(order, e.Event)
+		}
+	}
+	return order
+}
+
+// TestHandleSimulateStream tests the events streamed by both modes, the error event sent
+// once a stream has started, and the 400 returned before it starts.
+func TestHandleSimulateStream(t *testing.T) {
+	h := newTestHandler()
+
+	// Yearly periods run to December three years ahead, plus the rest of this year
+	now := time.Now()
+	targetYear := now.Year() + 3
+	years := 3
+	if now.Month() < time.December {
+		years++
+	}
+
+	tests := []struct {
+		name        string
+		path        string
+		body        string
+		accept      string
+		wantStatus  int
+		wantOrder   []string
+		wantUpdates int
+	}{
+		{
+			name:        "years",
+			path:        "/api/v1/simulate/years/stream",
+			body:        `{"years":2,"initialInvestment":10000,"monthlyContribution":500,"indexSymbol":"SPY","targetAmount":25000}`,
+			wantStatus:  http.StatusOK,
+			wantOrder:   []string{streamEventInputs, streamEventProjection, streamEventProgress, streamEventSummary},
+			wantUpdates: 24,
+		},
+		{
+			name:        "target as SSE with periods",
+			path:        "/api/v1/simulate/target/stream",
+			body:        fmt.Sprintf(`{"targetYear":%d,"targetMonth":12,"initialInvestment":10000,"indexSymbol":"QQQ","targetAmount":15000,"granularity":"yearly"}`, targetYear),
+			accept:      eventStreamContentType,
+			wantStatus:  http.StatusOK,
+			wantOrder:   []string{streamEventInputs, streamEventPeriod, streamEventProgress, streamEventSummary},
+			wantUpdates: years,
+		},
+		{
+			name:        "error after start",
+			path:        "/api/v1/simulate/years/stream",
+			body:        `{"years":2,"initialInvestment":10000,"indexSymbol":"` + testNoHistorySymbol + `","targetAmount":25000}`,
+			wantStatus:  http.StatusOK,
+			wantOrder:   []string{streamEventInputs, streamEventProjection, streamEventError},
+			wantUpdates: 24,
+		},
+		{
+			name:       "invalid request",
+			path:       "/api/v1/simulate/target/stream",
+			body:       `{"targetYear":2000}`,
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(h, tt.path, tt.body, http.Header{"Accept": {tt.accept}})
+			if w.Code != tt.wantStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.wantStatus, w.Code, w.Body.String())
+			}
+			if tt.wantStatus != http.StatusOK {
+				var resp ErrorResponse
+				if err := json.NewDecoder(w.Body).Decode(&resp); errors.Check(err) || resp.Error == "" {
+					t.Errorf("expected a JSON error before the stream starts, got %v", err)
+				}
+				return
+			}
+
+			events := readEvents(t, w)
+			if order := eventOrder(events); !slices.Equal(order, tt.wantOrder) {
+				t.Fatalf("expected events %v, got %v", tt.wantOrder, order)
+			}
+
+			updates := 0
+			for _, e := range events {
+				if e.Event == streamEventProjection || e.Event == streamEventPeriod {
+					updates++
+				}
+			}
+			if updates != tt.wantUpdates {
+				t.Errorf("expected %d projection events, got %d", tt.wantUpdates, updates)
+			}
+
+			last := events[len(events)-1]
+			if last.Event == streamEventSummary {
+				var progress StreamProgress
+				if err := json.Unmarshal(events[len(events)-2].Data, &progress); errors.Check(err) || progress.Percent != 100 {
+					t.Errorf("expected the last progress to be complete, got %+v", progress)
+				}
+			}
+		})
+	}
+}
+
+// TestStreamPlanCancelled tests that a stream stops without a summary or error once the client goes away.
+func TestStreamPlanCancelled(t *testing.T) {
+	h := newTestHandler()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	body := `{"years":10,"initialInvestment":10000,"indexSymbol":"SPY","targetAmount":25000}`
+	r := httptest.NewRequestWithContext(ctx, "POST", "/api/v1/simulate/years/stream", strings.NewReader(body))
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+
+	order := eventOrder(readEvents(t, w))
+	if want := []string{streamEventInputs, streamEventProjection}; !slices.Equal(order, want) {
+		t.Errorf("expected events %v, got %v", want, order)
+	}
+}
@@ -0,0 +1,321 @@
+// Package jobs provides an in-process worker pool for long-running work.
+// Jobs wait in a bounded queue, run on a fixed number of workers, and are kept
+// with their result for a time-to-live once finished.
+package jobs
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/abdonasmane/etfs-simulator/backend/internal/metrics"
+	"github.com/abdonasmane/etfs-simulator/backend/sdk/errors"
+)
+
+// Status is the state of a job.
+type Status string
+
+// Job statuses.
+const (
+	StatusQueued    Status = "queued"
+	StatusRunning   Status = "running"
+	StatusSucceeded Status = "succeeded"
+	StatusFailed    Status = "failed"
+	StatusCancelled Status = "cancelled"
+)
+
+var (
+	// ErrQueueFull is returned when a job is submitted while the queue is full.
+	ErrQueueFull = errors.New("job queue is full")
+
+	// ErrNotFound is returned for unknown or expired jobs.
+	ErrNotFound = errors.New("job not found")
+
+	// ErrFinished is returned when cancelling a job that has already finished.
+	ErrFinished = errors.New("job has already finished")
+
+	// ErrClosed is returned when a job is submitted after the manager was closed.
+	ErrClosed = errors.New("job manager is closed")
+)
+
+// Func is the work of a job. It should return early once ctx is cancelled, and may
+// report its progress as a fraction between 0 and 1.
+type Func func(ctx context.Context, progress func(float64)) (any, error)
+
+// Options contains all parameters needed to create a new Manager.
+type Options struct {
+	// Workers is the number of jobs run concurrently.
+	Workers int
+
+	// QueueSize is the maximum number of jobs waiting for a worker.
+	QueueSize int
+
+	// ResultTTL is how long a finished job and its result are kept.
+	ResultTTL time.Duration
+}
+
+// Job is a snapshot of a job's state.
+type Job struct {
+	ID       string
+	Type     string
+	Status   Status
+	Progress float64
+
+	// Result is set once the job has succeeded, Error once it has failed.
+	Result any
+	Error  string
+
+	CreatedAt  time.Time
+	StartedAt  *time.Time
+	FinishedAt *time.Time
+	ExpiresAt  *time.Time
+}
+
+// job is a submitted job and its work.
+type job struct {
+	Job
+
+	fn     Func
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// Manager queues jobs and runs them on a pool of workers.
+type Manager struct {
+	opts    Options
+	metrics *metrics.Metrics
+	queue   chan *job
+
+	mu   sync.Mutex
+	jobs map[string]*job
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	// now returns the current time; replaced in tests.
+	now func() time.Time
+}
+
+// New creates a Manager and starts its workers. Metrics may be nil.
+func New(opts Options, m *metrics.Metrics) *Manager {
+	ctx, cancel := context.WithCancel(context.Background())
+	mgr := &Manager{
+		opts:    opts,
+		metrics: m,
+		queue:   make(chan *job, opts.QueueSize),
+		jobs:    make(map[string]*job),
+		ctx:     ctx,
+		cancel:  cancel,
+		now:     time.Now,
+	}
+
+	for range opts.Workers {
+		mgr.wg.Go(mgr.work)
+	}
+
+	return mgr
+}
+
+// Close cancels all jobs and waits for the workers to stop. Jobs still waiting in the
+// queue are marked cancelled.
+func (m *Manager) Close() {
+	m.cancel()
+	m.wg.Wait()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for {
+		select {
+		case j := <-m.queue:
+			if j.Status == StatusQueued {
+				j.cancel()
+				m.finish(j, StatusCancelled)
+			}
+		default:
+			m.setQueueDepth()
+			return
+		}
+	}
+}
+
+// Submit queues a job of the given type. It returns ErrQueueFull if no room is left.
+func (m *Manager) Submit(typ string, fn Func) (Job, error) {
+	id, err := newID()
+	if errors.Check(err) {
+		return Job{}, err
+	}
+
+	ctx, cancel := context.WithCancel(m.ctx)
+	j := &job{
+		Job:    Job{ID: id, Type: typ, Status: StatusQueued, CreatedAt: m.now()},
+		fn:     fn,
+		ctx:    ctx,
+		cancel: cancel,
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sweep()
+
+	// Close empties the queue once, so nothing may be queued after it
+	if errors.Check(m.ctx.Err()) {
+		cancel()
+		return Job{}, ErrClosed
+	}
+
+	select {
+	case m.queue <- j:
+	default:
+		cancel()
+		return Job{}, ErrQueueFull
+	}
+	m.jobs[id] = j
+	m.setQueueDepth()
+
+	return j.Job, nil
+}
+
+// Get returns a snapshot of a job.
+func (m *Manager) Get(id string) (Job, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sweep()
+
+	j, ok := m.jobs[id]
+	if !ok {
+		return Job{}, ErrNotFound
+	}
+	return j.Job, nil
+}
+
+// Cancel cancels a queued or running job. A running job is reported as cancelled right
+// away; its worker is freed once its work returns.
+func (m *Manager) Cancel(id string) (Job, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sweep()
+
+	j, ok := m.jobs[id]
+	if !ok {
+		return Job{}, ErrNotFound
+	}
+	if j.Status != StatusQueued && j.Status != StatusRunning {
+		return j.Job, ErrFinished
+	}
+
+	j.cancel()
+	m.finish(j, StatusCancelled)
+	return j.Job, nil
+}
+
+// work runs queued jobs until the manager is closed.
+func (m *Manager) work() {
+	for {
+		select {
+		case <-m.ctx.Done():
+			return
+		case j := <-m.queue:
+			m.run(j)
+		}
+	}
+}
+
+// run runs a job and records its outcome, unless it was cancelled in the meantime.
+func (m *Manager) run(j *job) {
+	m.mu.Lock()
+	m.setQueueDepth()
+	if j.Status != StatusQueued {
+		m.mu.Unlock()
+		return
+	}
+	started := m.now()
+	j.Status = StatusRunning
+	j.StartedAt = &started
+	m.mu.Unlock()
+
+	// Progress never goes back, so reports from concurrent steps may arrive in any order
+	progress := func(p float64) {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		if j.Status == StatusRunning {
+			j.Progress = max(j.Progress, min(p, 1))
+		}
+	}
+
+	result, err := m.call(j, progress)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	defer j.cancel()
+	if j.Status != StatusRunning {
+		return
+	}
+
+	switch {
+	case j.ctx.Err() != nil:
+		m.finish(j, StatusCancelled)
+	case errors.Check(err):
+		j.Error = err.Error()
+		m.finish(j, StatusFailed)
+	default:
+		j.Result = result
+		j.Progress = 1
+		m.finish(j, StatusSucceeded)
+	}
+}
+
+// call runs a job's work, turning a panic into an error so one job cannot stop a worker.
+func (m *Manager) call(j *job, progress func(float64)) (result any, err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			slog.Error("job panicked", slog.String("id", j.ID), slog.String("type", j.Type), slog.Any("panic", p))
+			err = errors.New("internal error")
+		}
+	}()
+
+	return j.fn(j.ctx, progress)
+}
+
+// finish marks a job as finished with status and starts its time-to-live.
+// It must be called with the lock held.
+func (m *Manager) finish(j *job, status Status) {
+	finished := m.now()
+	expires := finished.Add(m.opts.ResultTTL)
+	j.Status = status
+	j.FinishedAt = &finished
+	j.ExpiresAt = &expires
+
+	if m.metrics != nil {
+		m.metrics.JobFinished(string(status))
+	}
+}
+
+// sweep removes expired jobs. It must be called with the lock held.
+func (m *Manager) sweep() {
+	now := m.now()
+	for id, j := range m.jobs {
+		if j.ExpiresAt != nil && !now.Before(*j.ExpiresAt) {
+			delete(m.jobs, id)
+		}
+	}
+}
+
+// setQueueDepth reports the number of queued jobs.
+func (m *Manager) setQueueDepth() {
+	if m.metrics != nil {
+		m.metrics.SetJobQueueDepth(len(m.queue))
+	}
+}
+
+// newID returns a random job ID.
+func newID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); errors.Check(err) {
+		return "", errors.Wrap(err, "generating job ID")
+	}
+	return hex.EncodeToString(b), nil
+}
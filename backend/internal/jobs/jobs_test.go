@@ -0,0 +1,158 @@
+package jobs
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/abdonasmane/etfs-simulator/backend/sdk/errors"
+)
+
+// waitFor polls a job until it reaches status or the test times out.
+func waitFor(t *testing.T, m *Manager, id string, status Status) Job {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		job, err := m.Get(id)
+		if errors.Check(err) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if job.Status == status {
+			return job
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("job %s did not reach status %s", id, status)
+	return Job{}
+}
+
+// TestManagerRunsJobs tests that jobs succeed or fail with their work's outcome.
+func TestManagerRunsJobs(t *testing.T) {
+	m := New(Options{Workers: 2, QueueSize: 4, ResultTTL: time.Minute}, nil)
+	defer m.Close()
+
+	ok, err := m.Submit("test", func(_ context.Context, progress func(float64)) (any, error) {
+		progress(0.5)
+		return 42, nil
+	})
+	if errors.Check(err) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	failing, err := m.Submit("test", func(context.Context, func(float64)) (any, error) {
+		return nil, errors.New("boom")
+	})
+	if errors.Check(err) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	job := waitFor(t, m, ok.ID, StatusSucceeded)
+	if job.Result != 42 || job.Progress != 1 || job.ExpiresAt == nil {
+		t.Errorf("unexpected succeeded job: %+v", job)
+	}
+	job = waitFor(t, m, failing.ID, StatusFailed)
+	if job.Error != "boom" || job.Result != nil {
+		t.Errorf("unexpected failed job: %+v", job)
+	}
+}
+
+// TestManagerQueueAndCancel tests the bounded queue and cancellation of queued and running jobs.
+func TestManagerQueueAndCancel(t *testing.T) {
+	m := New(Options{Workers: 1, QueueSize: 1, ResultTTL: time.Minute}, nil)
+	defer m.Close()
+
+	started := make(chan struct{})
+	running, err := m.Submit("test", func(ctx context.Context, _ func(float64)) (any, error) {
+		close(started)
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+	if errors.Check(err) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	<-started
+
+	idle := func(context.Context, func(float64)) (any, error) { return nil, nil }
+	queued, err := m.Submit("test", idle)
+	if errors.Check(err) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := m.Submit("test", idle); !errors.Is(err, ErrQueueFull) {
+		t.Fatalf("expected ErrQueueFull, got %v", err)
+	}
+
+	for _, id := range []string{queued.ID, running.ID} {
+		job, err := m.Cancel(id)
+		if errors.Check(err) || job.Status != StatusCancelled {
+			t.Errorf("expected job %s cancelled, got %+v (%v)", id, job, err)
+		}
+	}
+	if _, err := m.Cancel(running.ID); !errors.Is(err, ErrFinished) {
+		t.Errorf("expected ErrFinished, got %v", err)
+	}
+}
+
+// TestManagerCloseCancelsQueued tests that closing the manager cancels running and queued
+// jobs, and that no job can be submitted afterwards.
+func TestManagerCloseCancelsQueued(t *testing.T) {
+	m := New(Options{Workers: 1, QueueSize: 2, ResultTTL: time.Minute}, nil)
+
+	started := make(chan struct{})
+	running, err := m.Submit("test", func(ctx context.Context, _ func(float64)) (any, error) {
+		close(started)
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+	if errors.Check(err) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	<-started
+
+	ids := []string{running.ID}
+	for range 2 {
+		queued, err := m.Submit("test", func(context.Context, func(float64)) (any, error) { return "done", nil })
+		if errors.Check(err) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		ids = append(ids, queued.ID)
+	}
+
+	m.Close()
+
+	for _, id := range ids {
+		job, err := m.Get(id)
+		if errors.Check(err) || job.Status != StatusCancelled || job.ExpiresAt == nil {
+			t.Errorf("expected job %s cancelled with an expiry, got %+v (%v)", id, job, err)
+		}
+	}
+	if len(m.queue) != 0 {
+		t.Errorf("expected an empty queue, got %d jobs", len(m.queue))
+	}
+	if _, err := m.Submit("test", func(context.Context, func(float64)) (any, error) { return nil, nil }); !errors.Is(err, ErrClosed) {
+		t.Errorf("expected ErrClosed, got %v", err)
+	}
+}
+
+// TestManagerExpiresJobs tests that finished jobs are removed after their time-to-live.
+func TestManagerExpiresJobs(t *testing.T) {
+	m := New(Options{Workers: 1, QueueSize: 1, ResultTTL: time.Minute}, nil)
+	defer m.Close()
+
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	m.mu.Lock()
+	m.now = func() time.Time { return now }
+	m.mu.Unlock()
+
+	job, err := m.Submit("test", func(context.Context, func(float64)) (any, error) { return "done", nil })
+	if errors.Check(err) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	waitFor(t, m, job.ID, StatusSucceeded)
+
+	m.mu.Lock()
+	m.now = func() time.Time { return now.Add(time.Minute) }
+	m.mu.Unlock()
+
+	if _, err := m.Get(job.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound after expiry, got %v", err)
+	}
+}
@@ -3,17 +3,34 @@ package marketdata
 
 import (
 	"log/slog"
+	"sort"
 	"sync"
 	"time"
 
 	"github.com/abdonasmane/etfs-simulator/backend/sdk/errors"
 )
 
+// Asset classes of supported indexes.
+const (
+	AssetClassEquity = "equity"
+	AssetClassBond   = "bond"
+	AssetClassCash   = "cash"
+)
+
+// Return bases describe where an index's forward returns come from.
+const (
+	ReturnBasisHistorical = "historical" // Rolling historical returns
+	ReturnBasisYield      = "yield"      // Current yield, with the historical spread around it
+	ReturnBasisInterest   = "interest"   // A fixed interest rate
+)
+
 // IndexInfo contains metadata and statistics for a market index.
 type IndexInfo struct {
 	Symbol             string  `json:"symbol"`
 	Name               string  `json:"name"`
 	Description        string  `json:"description"`
+	AssetClass         string  `json:"assetClass"`
+	ReturnBasis        string  `json:"returnBasis"`
 	MedianReturn       float64 `json:"medianReturn"`      // 50th percentile
 	PessimisticReturn  float64 `json:"pessimisticReturn"` // 5th percentile
 	OptimisticReturn   float64 `json:"optimisticReturn"`  // 95th percentile
@@ -21,6 +38,12 @@ type IndexInfo struct {
 	DataYears          float64 `json:"dataYears"`
 	DataStartDate      string  `json:"dataStartDate"`
 	RollingPeriodYears int     `json:"rollingPeriodYears"` // e.g., 10 or 20 years
+	LastPrice          float64 `json:"lastPrice"`          // Most recent close
+	Currency           string  `json:"currency"`
+	ExpenseRatio       float64 `json:"expenseRatio"` // Annual fund fee (TER), percent
+
+	// Yield is the trailing 12-month distribution yield, present for bond ETFs.
+	Yield *float64 `json:"yield,omitempty"`
 }
 
 // SupportedIndex defines a supported index with its ETF symbol.
@@ -28,22 +51,54 @@ type SupportedIndex struct {
 	Symbol      string
 	Name        string
 	Description string
+	AssetClass  string
 }
 
 // DefaultSupportedIndexes are the indexes we support out of the box.
 var DefaultSupportedIndexes = []SupportedIndex{
-	{Symbol: "SPY", Name: "S&P 500", Description: "500 largest US companies"},
-	{Symbol: "QQQ", Name: "NASDAQ 100", Description: "100 largest non-financial NASDAQ companies"},
-	{Symbol: "EFA", Name: "MSCI EAFE", Description: "Developed markets excluding US & Canada"},
+	{Symbol: "SPY", Name: "S&P 500", Description: "500 largest US companies", AssetClass: AssetClassEquity},
+	{Symbol: "QQQ", Name: "NASDAQ 100", Description: "100 largest non-financial NASDAQ companies", AssetClass: AssetClassEquity},
+	{Symbol: "EFA", Name: "MSCI EAFE", Description: "Developed markets excluding US & Canada", AssetClass: AssetClassEquity},
+	{Symbol: "AGG", Name: "US Aggregate Bond", Description: "Investment-grade US bonds", AssetClass: AssetClassBond},
+	{Symbol: "BND", Name: "US Total Bond Market", Description: "Broad investment-grade US bond market", AssetClass: AssetClassBond},
+	{Symbol: "IEF", Name: "7-10 Year Treasury", Description: "Intermediate-term US Treasury bonds", AssetClass: AssetClassBond},
+}
+
+// ExpenseRatios are the annual fund fees (TER, percent) of supported ETFs and of common
+// near-identical alternatives, as published by their issuers.
+var ExpenseRatios = map[string]float64{
+	"SPY":  0.0945,
+	"VOO":  0.03,
+	"IVV":  0.03,
+	"SPLG": 0.02,
+	"QQQ":  0.20,
+	"QQQM": 0.15,
+	"EFA":  0.32,
+	"IEFA": 0.07,
+	"VEA":  0.05,
+	"AGG":  0.03,
+	"BND":  0.03,
+	"IEF":  0.15,
+}
+
+// ReturnMatrix holds the monthly returns of several symbols over the months they share.
+type ReturnMatrix struct {
+	Symbols []string
+	Dates   []time.Time
+	Returns [][]float64 // Returns[month][symbol], decimal
 }
 
 // IndexService provides cached access to index statistics.
 type IndexService struct {
 	client     *YahooClient
 	cache      map[string]*IndexInfo
+	returns    map[string][]MonthlyReturn
 	cacheMutex sync.RWMutex
 	lastUpdate time.Time
 	cacheTTL   time.Duration
+
+	// version increases every time index data is loaded.
+	version uint64
 }
 
 // NewIndexService creates a new index service.
@@ -51,6 +106,7 @@ func NewIndexService() *IndexService {
 	return &IndexService{
 		client:   NewYahooClient(),
 		cache:    make(map[string]*IndexInfo),
+		returns:  make(map[string][]MonthlyReturn),
 		cacheTTL: 24 * time.Hour, // Refresh daily
 	}
 }
@@ -61,7 +117,7 @@ func (s *IndexService) Initialize() error {
 	slog.Info("initializing index service, fetching historical data...")
 
 	for _, idx := range DefaultSupportedIndexes {
-		info, err := s.fetchAndCalculate(idx)
+		info, returns, err := s.fetchAndCalculate(idx)
 		if errors.Check(err) {
 			slog.Error("failed to fetch index data",
 				slog.String("symbol", idx.Symbol),
@@ -71,9 +127,7 @@ func (s *IndexService) Initialize() error {
 			continue
 		}
 
-		s.cacheMutex.Lock()
-		s.cache[idx.Symbol] = info
-		s.cacheMutex.Unlock()
+		s.Load(info, returns)
 
 		slog.Info("loaded index data",
 			slog.String("symbol", idx.Symbol),
@@ -95,11 +149,11 @@ func (s *IndexService) Initialize() error {
 	return nil
 }
 
-// fetchAndCalculate fetches data from Yahoo and calculates statistics.
-func (s *IndexService) fetchAndCalculate(idx SupportedIndex) (*IndexInfo, error) {
+// fetchAndCalculate fetches data from Yahoo and calculates statistics and monthly returns.
+func (s *IndexService) fetchAndCalculate(idx SupportedIndex) (*IndexInfo, []MonthlyReturn, error) {
 	data, err := s.client.FetchHistoricalData(idx.Symbol, "1mo", "max")
 	if errors.Check(err) {
-		return nil, errors.Wrap(err, "fetching historical data")
+		return nil, nil, errors.Wrap(err, "fetching historical data")
 	}
 
 	// Try 20-year rolling first, fall back to 10-year if not enough data
@@ -109,14 +163,18 @@ func (s *IndexService) fetchAndCalculate(idx SupportedIndex) (*IndexInfo, error)
 		rollingYears = 10
 		stats, err = s.client.CalculateStats(data, rollingYears)
 		if errors.Check(err) {
-			return nil, errors.Wrap(err, "calculating statistics")
+			return nil, nil, errors.Wrap(err, "calculating statistics")
 		}
 	}
 
-	return &IndexInfo{
+	last := data.DataPoints[len(data.DataPoints)-1]
+
+	info := &IndexInfo{
 		Symbol:             idx.Symbol,
 		Name:               idx.Name,
 		Description:        idx.Description,
+		AssetClass:         idx.AssetClass,
+		ReturnBasis:        ReturnBasisHistorical,
 		MedianReturn:       roundTo2Decimals(stats.AnnualizedReturn),
 		PessimisticReturn:  roundTo2Decimals(stats.Percentile5Return),
 		OptimisticReturn:   roundTo2Decimals(stats.Percentile95Return),
@@ -124,7 +182,40 @@ func (s *IndexService) fetchAndCalculate(idx SupportedIndex) (*IndexInfo, error)
 		DataYears:          roundTo1Decimal(stats.TotalYears),
 		DataStartDate:      stats.DataStartDate.Format("Jan 2006"),
 		RollingPeriodYears: rollingYears,
-	}, nil
+		LastPrice:          roundTo2Decimals(last.Close),
+		Currency:           data.Currency,
+		ExpenseRatio:       ExpenseRatios[idx.Symbol],
+	}
+
+	// A bond fund's starting yield is a better guide to its future return than its past
+	if idx.AssetClass == AssetClassBond {
+		if yield, ok := s.client.TrailingYield(data); ok {
+			applyYieldReturns(info, stats, yield)
+		}
+	}
+
+	return info, s.client.CalculateMonthlyReturns(data), nil
+}
+
+// applyYieldReturns centers an index's return range on its current yield, keeping the
+// historical spread of rolling returns around their median.
+func applyYieldReturns(info *IndexInfo, stats *IndexStats, yield float64) {
+	rounded := roundTo2Decimals(yield)
+	info.ReturnBasis = ReturnBasisYield
+	info.Yield = &rounded
+	info.MedianReturn = roundTo2Decimals(yield)
+	info.PessimisticReturn = roundTo2Decimals(yield - (stats.AnnualizedReturn - stats.Percentile5Return))
+	info.OptimisticReturn = roundTo2Decimals(yield + (stats.Percentile95Return - stats.AnnualizedReturn))
+}
+
+// Load caches an index's info and monthly return history, replacing any data for its symbol.
+func (s *IndexService) Load(info *IndexInfo, returns []MonthlyReturn) {
+	s.cacheMutex.Lock()
+	defer s.cacheMutex.Unlock()
+
+	s.cache[info.Symbol] = info
+	s.returns[info.Symbol] = returns
+	s.version++
 }
 
 // GetIndex returns cached index info for a symbol.
@@ -136,6 +227,25 @@ func (s *IndexService) GetIndex(symbol string) (*IndexInfo, bool) {
 	return info, ok
 }
 
+// Version identifies the loaded index data. It changes whenever data is loaded, so results
+// derived from the data can be cached per version.
+func (s *IndexService) Version() uint64 {
+	s.cacheMutex.RLock()
+	defer s.cacheMutex.RUnlock()
+
+	return s.version
+}
+
+// GetExpenseRatio returns the expense ratio of a symbol from its cached index info or the known ETFs.
+func (s *IndexService) GetExpenseRatio(symbol string) (float64, bool) {
+	if info, ok := s.GetIndex(symbol); ok && info.ExpenseRatio > 0 {
+		return info.ExpenseRatio, true
+	}
+
+	ratio, ok := ExpenseRatios[symbol]
+	return ratio, ok
+}
+
 // GetAllIndexes returns all cached index info.
 func (s *IndexService) GetAllIndexes() []*IndexInfo {
 	s.cacheMutex.RLock()
@@ -148,6 +258,84 @@ func (s *IndexService) GetAllIndexes() []*IndexInfo {
 	return result
 }
 
+// GetMonthlyReturns returns the cached monthly return history for a symbol.
+func (s *IndexService) GetMonthlyReturns(symbol string) ([]MonthlyReturn, bool) {
+	s.cacheMutex.RLock()
+	defer s.cacheMutex.RUnlock()
+
+	returns, ok := s.returns[symbol]
+	return returns, ok && len(returns) > 0
+}
+
+// AlignedMonthlyReturns returns the monthly returns of the given symbols restricted
+// to the months for which every symbol has data, ordered chronologically.
+func (s *IndexService) AlignedMonthlyReturns(symbols []string) (*ReturnMatrix, error) {
+	if len(symbols) == 0 {
+		return nil, errors.New("no symbols provided")
+	}
+
+	s.cacheMutex.RLock()
+	defer s.cacheMutex.RUnlock()
+
+	// Index each symbol's returns by calendar month
+	byMonth := make([]map[int]float64, len(symbols))
+	for i, symbol := range symbols {
+		returns, ok := s.returns[symbol]
+		if !ok || len(returns) == 0 {
+			return nil, errors.Errorf("no return history for symbol %s", symbol)
+		}
+
+		byMonth[i] = make(map[int]float64, len(returns))
+		for _, r := range returns {
+			byMonth[i][monthKey(r.Date)] = r.Return
+		}
+	}
+
+	// Keep only the months shared by all symbols
+	dates := make([]time.Time, 0, len(byMonth[0]))
+	seen := make(map[int]bool, len(byMonth[0]))
+	for _, r := range s.returns[symbols[0]] {
+		key := monthKey(r.Date)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		shared := true
+		for _, m := range byMonth[1:] {
+			if _, ok := m[key]; !ok {
+				shared = false
+				break
+			}
+		}
+		if shared {
+			dates = append(dates, r.Date)
+		}
+	}
+
+	if len(dates) == 0 {
+		return nil, errors.New("symbols have no overlapping return history")
+	}
+
+	sort.Slice(dates, func(i, j int) bool { return dates[i].Before(dates[j]) })
+
+	matrix := &ReturnMatrix{
+		Symbols: append([]string(nil), symbols...),
+		Dates:   dates,
+		Returns: make([][]float64, len(dates)),
+	}
+	for t, date := range dates {
+		key := monthKey(date)
+		row := make([]float64, len(symbols))
+		for i := range symbols {
+			row[i] = byMonth[i][key]
+		}
+		matrix.Returns[t] = row
+	}
+
+	return matrix, nil
+}
+
 // RefreshIfNeeded refreshes the cache if TTL has expired.
 func (s *IndexService) RefreshIfNeeded() {
 	if time.Since(s.lastUpdate) < s.cacheTTL {
@@ -162,6 +350,11 @@ func (s *IndexService) RefreshIfNeeded() {
 	}()
 }
 
+// monthKey returns a sortable integer key identifying the calendar month of a date.
+func monthKey(t time.Time) int {
+	return t.Year()*12 + int(t.Month()) - 1
+}
+
 // roundTo2Decimals rounds a float to 2 decimal places.
 func roundTo2Decimals(v float64) float64 {
 	return float64(int(v*100+0.5)) / 100
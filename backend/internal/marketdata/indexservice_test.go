@@ -0,0 +1,135 @@
+package marketdata
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/abdonasmane/etfs-simulator/backend/sdk/errors"
+)
+
+// month returns the first day of a month in UTC.
+func month(year int, m time.Month) time.Time {
+	return time.Date(year, m, 1, 0, 0, 0, 0, time.UTC)
+}
+
+// TestCalculateMonthlyReturns tests month-over-month returns from adjusted closes.
+func TestCalculateMonthlyReturns(t *testing.T) {
+	client := NewYahooClient()
+	data := &HistoricalData{
+		Symbol:   "TEST",
+		Interval: "1mo",
+		DataPoints: []PricePoint{
+			{Date: month(2020, time.January), AdjClose: 100},
+			{Date: month(2020, time.February), AdjClose: 110},
+			{Date: month(2020, time.March), AdjClose: 0}, // missing data
+			{Date: month(2020, time.April), AdjClose: 99},
+		},
+	}
+
+	returns := client.CalculateMonthlyReturns(data)
+	if len(returns) != 1 {
+		t.Fatalf("expected 1 return, got %d", len(returns))
+	}
+	if math.Abs(returns[0].Return-0.10) > 1e-9 {
+		t.Errorf("expected 10%% return, got %.4f", returns[0].Return)
+	}
+	if !returns[0].Date.Equal(month(2020, time.February)) {
+		t.Errorf("expected February 2020, got %s", returns[0].Date.Format("Jan 2006"))
+	}
+}
+
+// TestAlignedMonthlyReturns tests that returns are restricted to shared months.
+func TestAlignedMonthlyReturns(t *testing.T) {
+	s := NewIndexService()
+	s.returns["AAA"] = []MonthlyReturn{
+		{Date: month(2020, time.January), Return: 0.01},
+		{Date: month(2020, time.February), Return: 0.02},
+		{Date: month(2020, time.March), Return: 0.03},
+	}
+	s.returns["BBB"] = []MonthlyReturn{
+		{Date: month(2020, time.February), Return: -0.02},
+		{Date: month(2020, time.March), Return: -0.03},
+		{Date: month(2020, time.April), Return: -0.04},
+	}
+
+	matrix, err := s.AlignedMonthlyReturns([]string{"AAA", "BBB"})
+	if errors.Check(err) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(matrix.Dates) != 2 {
+		t.Fatalf("expected 2 shared months, got %d", len(matrix.Dates))
+	}
+	if matrix.Returns[0][0] != 0.02 || matrix.Returns[0][1] != -0.02 {
+		t.Errorf("unexpected first row: %v", matrix.Returns[0])
+	}
+	if matrix.Returns[1][0] != 0.03 || matrix.Returns[1][1] != -0.03 {
+		t.Errorf("unexpected second row: %v", matrix.Returns[1])
+	}
+
+	if _, err := s.AlignedMonthlyReturns([]string{"AAA", "MISSING"}); !errors.Check(err) {
+		t.Error("expected error for unknown symbol")
+	}
+}
+
+// TestLoad tests that loading an index replaces its data and changes the version.
+func TestLoad(t *testing.T) {
+	s := NewIndexService()
+	returns := []MonthlyReturn{{Date: month(2020, time.January), Return: 0.01}}
+
+	s.Load(&IndexInfo{Symbol: "AAA", MedianReturn: 7}, returns)
+	s.Load(&IndexInfo{Symbol: "AAA", MedianReturn: 8}, returns)
+
+	info, ok := s.GetIndex("AAA")
+	if !ok || info.MedianReturn != 8 {
+		t.Errorf("expected the second load to replace the first, got %+v", info)
+	}
+	if _, ok := s.GetMonthlyReturns("AAA"); !ok {
+		t.Error("expected the loaded returns")
+	}
+	if s.Version() != 2 {
+		t.Errorf("expected version 2, got %d", s.Version())
+	}
+}
+
+// TestTrailingYield tests that only the last 12 months of distributions count towards the yield.
+func TestTrailingYield(t *testing.T) {
+	client := NewYahooClient()
+	data := &HistoricalData{
+		Symbol:     "BOND",
+		Interval:   "1mo",
+		DataPoints: []PricePoint{{Date: month(2025, time.June), Close: 100}},
+		Dividends: []Dividend{
+			{Date: month(2024, time.May), Amount: 5}, // older than a year
+			{Date: month(2024, time.September), Amount: 2},
+			{Date: month(2025, time.March), Amount: 2},
+		},
+		FetchedAt: month(2025, time.June),
+	}
+
+	yield, ok := client.TrailingYield(data)
+	if !ok || math.Abs(yield-4) > 1e-9 {
+		t.Errorf("expected a 4%% yield, got %.4f (%v)", yield, ok)
+	}
+
+	data.Dividends = nil
+	if _, ok := client.TrailingYield(data); ok {
+		t.Error("expected no yield without distributions")
+	}
+}
+
+// TestApplyYieldReturns tests that the return range is centered on the yield.
+func TestApplyYieldReturns(t *testing.T) {
+	info := &IndexInfo{ReturnBasis: ReturnBasisHistorical, MedianReturn: 3}
+	stats := &IndexStats{AnnualizedReturn: 3, Percentile5Return: 1.5, Percentile95Return: 5}
+
+	applyYieldReturns(info, stats, 4.2)
+
+	if info.ReturnBasis != ReturnBasisYield || info.Yield == nil || *info.Yield != 4.2 {
+		t.Errorf("expected a yield basis of 4.2, got %s %v", info.ReturnBasis, info.Yield)
+	}
+	if info.MedianReturn != 4.2 || info.PessimisticReturn != 2.7 || info.OptimisticReturn != 6.2 {
+		t.Errorf("unexpected range: %.2f %.2f %.2f", info.PessimisticReturn, info.MedianReturn, info.OptimisticReturn)
+	}
+}
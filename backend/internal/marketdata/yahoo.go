@@ -50,6 +50,12 @@ type YahooResponse struct {
 					AdjClose []float64 `json:"adjclose"`
 				} `json:"adjclose"`
 			} `json:"indicators"`
+			Events struct {
+				Dividends map[string]struct {
+					Amount float64 `json:"amount"`
+					Date   int64   `json:"date"`
+				} `json:"dividends"`
+			} `json:"events"`
 		} `json:"result"`
 		Error *struct {
 			Code        string `json:"code"`
@@ -75,9 +81,16 @@ type HistoricalData struct {
 	Currency   string
 	Interval   string // Data interval: "1d", "1wk", "1mo", etc.
 	DataPoints []PricePoint
+	Dividends  []Dividend // Chronological distributions
 	FetchedAt  time.Time
 }
 
+// Dividend is a distribution paid per share.
+type Dividend struct {
+	Date   time.Time
+	Amount float64
+}
+
 // PointsPerYear returns the expected number of data points per year for a given interval.
 func PointsPerYear(interval string) int {
 	switch interval {
@@ -108,9 +121,15 @@ type IndexStats struct {
 	CalculatedAt       time.Time
 }
 
+// MonthlyReturn is the total return of a symbol over a single month.
+type MonthlyReturn struct {
+	Date   time.Time
+	Return float64 // Decimal return, e.g. 0.012 for +1.2%
+}
+
 // FetchHistoricalData fetches historical monthly data for a symbol.
 func (c *YahooClient) FetchHistoricalData(symbol, interval, rangePeriod string) (*HistoricalData, error) {
-	url := fmt.Sprintf("%s/%s?interval=%s&range=%s", c.baseURL, symbol, interval, rangePeriod)
+	url := fmt.Sprintf("%s/%s?interval=%s&range=%s&events=div", c.baseURL, symbol, interval, rangePeriod)
 
 	req, err := http.NewRequest(http.MethodGet, url, nil)
 	if errors.Check(err) {
@@ -193,9 +212,43 @@ func (c *YahooClient) FetchHistoricalData(symbol, interval, rangePeriod string)
 		data.DataPoints = append(data.DataPoints, point)
 	}
 
+	for _, div := range result.Events.Dividends {
+		data.Dividends = append(data.Dividends, Dividend{
+			Date:   time.Unix(div.Date, 0).UTC(),
+			Amount: div.Amount,
+		})
+	}
+	sort.Slice(data.Dividends, func(i, j int) bool { return data.Dividends[i].Date.Before(data.Dividends[j].Date) })
+
 	return data, nil
 }
 
+// TrailingYield returns the distributions of the 12 months before the data was fetched as a
+// percentage of the last close. It returns false when there were no distributions or no price.
+func (c *YahooClient) TrailingYield(data *HistoricalData) (float64, bool) {
+	if len(data.DataPoints) == 0 || len(data.Dividends) == 0 {
+		return 0, false
+	}
+
+	last := data.DataPoints[len(data.DataPoints)-1]
+	if last.Close <= 0 {
+		return 0, false
+	}
+
+	from := data.FetchedAt.AddDate(-1, 0, 0)
+	var paid float64
+	for _, div := range data.Dividends {
+		if div.Date.After(from) && !div.Date.After(data.FetchedAt) {
+			paid += div.Amount
+		}
+	}
+	if paid == 0 {
+		return 0, false
+	}
+
+	return paid / last.Close * 100, true
+}
+
 // CalculateStats computes statistical analysis from historical data.
 // rollingYears specifies the rolling period for calculating returns (e.g., 20 for 20-year returns).
 func (c *YahooClient) CalculateStats(data *HistoricalData, rollingYears int) (*IndexStats, error) {
@@ -255,6 +308,35 @@ func (c *YahooClient) CalculateStats(data *HistoricalData, rollingYears int) (*I
 	return stats, nil
 }
 
+// CalculateMonthlyReturns computes month-over-month total returns from adjusted close prices.
+func (c *YahooClient) CalculateMonthlyReturns(data *HistoricalData) []MonthlyReturn {
+	if len(data.DataPoints) < 2 {
+		return nil
+	}
+
+	returns := make([]MonthlyReturn, 0, len(data.DataPoints)-1)
+	for i := 1; i < len(data.DataPoints); i++ {
+		prev := data.DataPoints[i-1].AdjClose
+		curr := data.DataPoints[i].AdjClose
+
+		if prev <= 0 || curr <= 0 {
+			continue
+		}
+
+		r := curr/prev - 1
+
+		// Filter out unrealistic returns (data errors)
+		if r > -0.5 && r < 1 {
+			returns = append(returns, MonthlyReturn{
+				Date:   data.DataPoints[i].Date,
+				Return: r,
+			})
+		}
+	}
+
+	return returns
+}
+
 // percentile calculates the p-th percentile of a sorted slice.
 func percentile(sorted []float64, p float64) float64 {
 	if len(sorted) == 0 {
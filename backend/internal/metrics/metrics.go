@@ -4,6 +4,7 @@ package metrics
 import (
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
@@ -16,6 +17,10 @@ type Metrics struct {
 	httpRequestsTotal    *prometheus.CounterVec
 	httpRequestDuration  *prometheus.HistogramVec
 	httpRequestsInFlight prometheus.Gauge
+	jobsQueueDepth       prometheus.Gauge
+	jobsFinishedTotal    *prometheus.CounterVec
+	cacheHitsTotal       *prometheus.CounterVec
+	cacheMissesTotal     *prometheus.CounterVec
 }
 
 // New creates and registers all Prometheus metrics.
@@ -42,9 +47,56 @@ func New() *Metrics {
 				Help: "Current number of HTTP requests being processed.",
 			},
 		),
+		jobsQueueDepth: promauto.NewGauge(
+			prometheus.GaugeOpts{
+				Name: "jobs_queue_depth",
+				Help: "Current number of jobs waiting for a worker.",
+			},
+		),
+		jobsFinishedTotal: promauto.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "jobs_finished_total",
+				Help: "Total number of finished jobs by status.",
+			},
+			[]string{"status"},
+		),
+		cacheHitsTotal: promauto.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "simulation_cache_hits_total",
+				Help: "Total number of simulation requests served from the result cache by endpoint.",
+			},
+			[]string{"endpoint"},
+		),
+		cacheMissesTotal: promauto.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "simulation_cache_misses_total",
+				Help: "Total number of simulation requests computed on a result cache miss by endpoint.",
+			},
+			[]string{"endpoint"},
+		),
 	}
 }
 
+// SetJobQueueDepth records the number of jobs waiting for a worker.
+func (m *Metrics) SetJobQueueDepth(depth int) {
+	m.jobsQueueDepth.Set(float64(depth))
+}
+
+// JobFinished counts a finished job by its final status.
+func (m *Metrics) JobFinished(status string) {
+	m.jobsFinishedTotal.WithLabelValues(status).Inc()
+}
+
+// CacheHit counts a simulation request served from the result cache.
+func (m *Metrics) CacheHit(endpoint string) {
+	m.cacheHitsTotal.WithLabelValues(endpoint).Inc()
+}
+
+// CacheMiss counts a simulation request computed on a result cache miss.
+func (m *Metrics) CacheMiss(endpoint string) {
+	m.cacheMissesTotal.WithLabelValues(endpoint).Inc()
+}
+
 // Handler returns the Prometheus metrics HTTP handler.
 func Handler() http.Handler {
 	return promhttp.Handler()
@@ -106,6 +158,11 @@ func (rw *responseWriter) Flush() {
 	}
 }
 
+// Unwrap returns the wrapped writer so http.ResponseController can reach it.
+func (rw *responseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
+
 // normalizePath normalizes the request path for metrics labels.
 // This prevents high cardinality from dynamic path segments.
 func normalizePath(path string) string {
@@ -115,6 +172,14 @@ func normalizePath(path string) string {
 		return path
 	}
 
+	// Normalize job paths, which contain the job ID
+	if rest, ok := strings.CutPrefix(path, "/api/v1/jobs/"); ok {
+		if strings.HasSuffix(rest, "/result") {
+			return "/api/v1/jobs/{id}/result"
+		}
+		return "/api/v1/jobs/{id}"
+	}
+
 	// Normalize API paths
 	if len(path) > 4 && path[:4] == "/api" {
 		return path
@@ -0,0 +1,153 @@
+package portfolio
+
+import (
+	"math"
+	"slices"
+
+	"github.com/abdonasmane/etfs-simulator/backend/sdk/errors"
+)
+
+const (
+	// minDrawdownStep is the smallest weight shift tried by the drawdown search.
+	minDrawdownStep = 1e-4
+
+	// maxDrawdownEvaluations bounds the drawdown search.
+	maxDrawdownEvaluations = 20000
+)
+
+// EqualWeight returns the same weight for each of n assets.
+func EqualWeight(n int) []float64 {
+	weights := make([]float64, n)
+	for i := range weights {
+		weights[i] = 1 / float64(n)
+	}
+	return weights
+}
+
+// InverseVolatility returns weights proportional to the inverse of each asset's volatility.
+func InverseVolatility(e Estimates) ([]float64, error) {
+	weights := make([]float64, len(e.Mean))
+	var total float64
+	for i := range weights {
+		volatility := math.Sqrt(e.Cov[i][i])
+		if volatility < 1e-12 {
+			return nil, errors.New("inverse volatility requires every asset to be volatile")
+		}
+		weights[i] = 1 / volatility
+		total += weights[i]
+	}
+
+	for i := range weights {
+		weights[i] /= total
+	}
+	return weights, nil
+}
+
+// EqualRiskContribution returns the risk parity weights, with which every asset contributes
+// the same share of the portfolio variance. It minimizes y'Cov y / 2 - sum(log y) / n by
+// cyclical coordinate descent and normalizes the solution.
+func EqualRiskContribution(e Estimates) ([]float64, error) {
+	n := len(e.Mean)
+	budget := 1 / float64(n)
+
+	y, err := InverseVolatility(e)
+	if errors.Check(err) {
+		return nil, errors.New("risk parity requires every asset to be volatile")
+	}
+
+	for range maxIterations {
+		change := 0.0
+		for i := range n {
+			// Solve Cov[i][i] y_i^2 + c y_i - budget = 0 for the positive root
+			var c float64
+			for j := range n {
+				if j != i {
+					c += e.Cov[i][j] * y[j]
+				}
+			}
+			next := (-c + math.Sqrt(c*c+4*e.Cov[i][i]*budget)) / (2 * e.Cov[i][i])
+			change = max(change, math.Abs(next-y[i])/next)
+			y[i] = next
+		}
+		if change < tolerance {
+			break
+		}
+	}
+
+	var total float64
+	for _, v := range y {
+		total += v
+	}
+	for i := range y {
+		y[i] /= total
+	}
+	return y, nil
+}
+
+// MinDrawdown returns the weights whose monthly-rebalanced portfolio had the smallest maximum
+// drawdown over returns ([month][asset]). Starting from equal weights, it shifts weight between
+// pairs of assets while the drawdown improves, halving the shift when no move does.
+func MinDrawdown(returns [][]float64) []float64 {
+	n := len(returns[0])
+	weights := EqualWeight(n)
+	best := MaxDrawdown(returns, weights)
+
+	evaluations := 0
+	for step := 0.1; step >= minDrawdownStep && evaluations < maxDrawdownEvaluations; {
+		improved := false
+		for i := range n {
+			for j := range n {
+				shift := min(step, weights[j])
+				if i == j || shift <= 0 {
+					continue
+				}
+
+				candidate := slices.Clone(weights)
+				candidate[i] += shift
+				candidate[j] -= shift
+				evaluations++
+				if drawdown := MaxDrawdown(returns, candidate); drawdown < best-1e-12 {
+					weights, best = candidate, drawdown
+					improved = true
+				}
+			}
+		}
+		if !improved {
+			step /= 2
+		}
+	}
+
+	return weights
+}
+
+// MaxDrawdown is the largest peak-to-trough loss of the monthly-rebalanced portfolio of
+// weights over returns ([month][asset]), as a fraction of the peak.
+func MaxDrawdown(returns [][]float64, weights []float64) float64 {
+	value, peak, drawdown := 1.0, 1.0, 0.0
+	for _, row := range returns {
+		var r float64
+		for i, w := range weights {
+			r += w * row[i]
+		}
+		value *= 1 + r
+		peak = max(peak, value)
+		drawdown = max(drawdown, 1-value/peak)
+	}
+	return drawdown
+}
+
+// RiskContributions returns each asset's share of the portfolio variance of weights
+// (all zero for a riskless portfolio).
+func RiskContributions(e Estimates, weights []float64) []float64 {
+	contributions := make([]float64, len(weights))
+	variance := e.variance(weights)
+	if variance < 1e-24 {
+		return contributions
+	}
+
+	marginal := e.covTimes(weights)
+	for i, w := range weights {
+		contributions[i] = w * marginal[i] / variance
+	}
+	return contributions
+}
@@ -0,0 +1,75 @@
+package portfolio
+
+import (
+	"math"
+	"testing"
+
+	"github.com/abdonasmane/etfs-simulator/backend/sdk/errors"
+)
+
+// TestInverseVolatility tests weights proportional to inverse volatility.
+func TestInverseVolatility(t *testing.T) {
+	weights, err := InverseVolatility(twoAssets())
+	if errors.Check(err) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// Volatilities 20% and 10%: weights 1/3 and 2/3
+	if math.Abs(weights[0]-1.0/3) > 1e-9 || math.Abs(weights[1]-2.0/3) > 1e-9 {
+		t.Errorf("expected weights [1/3 2/3], got %v", weights)
+	}
+
+	cash := Estimates{Mean: []float64{0.08, 0.03}, Cov: [][]float64{{0.04, 0}, {0, 0}}}
+	if _, err := InverseVolatility(cash); !errors.Check(err) {
+		t.Error("expected an error for a riskless asset")
+	}
+}
+
+// TestEqualRiskContribution tests that every asset contributes the same share of variance.
+func TestEqualRiskContribution(t *testing.T) {
+	cov := [][]float64{
+		{0.04, 0.006, 0.002},
+		{0.006, 0.09, -0.003},
+		{0.002, -0.003, 0.01},
+	}
+	e := Estimates{Mean: []float64{0.08, 0.1, 0.03}, Cov: cov}
+
+	weights, err := EqualRiskContribution(e)
+	if errors.Check(err) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var total float64
+	for i, rc := range RiskContributions(e, weights) {
+		total += weights[i]
+		if math.Abs(rc-1.0/3) > 1e-6 {
+			t.Errorf("asset %d: expected risk contribution 1/3, got %v", i, rc)
+		}
+	}
+	if math.Abs(total-1) > 1e-9 {
+		t.Errorf("expected weights to sum to 1, got %v", total)
+	}
+}
+
+// TestMinDrawdown tests that the drawdown search avoids the asset that crashed.
+func TestMinDrawdown(t *testing.T) {
+	returns := [][]float64{{0.02, 0.01}, {-0.30, 0.01}, {0.05, 0.01}, {0.02, -0.02}}
+
+	weights := MinDrawdown(returns)
+	if MaxDrawdown(returns, weights) > MaxDrawdown(returns, EqualWeight(2)) {
+		t.Errorf("expected a drawdown below equal weight, got weights %v", weights)
+	}
+	if weights[0] > 0.1 {
+		t.Errorf("expected little weight in the crashing asset, got %v", weights)
+	}
+}
+
+// TestMaxDrawdown tests the peak-to-trough loss of a rebalanced portfolio.
+func TestMaxDrawdown(t *testing.T) {
+	returns := [][]float64{{0.10}, {-0.20}, {0.05}, {-0.10}}
+
+	// Peak 1.1, trough 1.1 * 0.8 * 1.05 * 0.9 = 0.8316
+	want := 1 - 0.8316/1.1
+	if got := MaxDrawdown(returns, []float64{1}); math.Abs(got-want) > 1e-12 {
+		t.Errorf("expected drawdown %v, got %v", want, got)
+	}
+}
@@ -0,0 +1,140 @@
+// Package portfolio builds portfolio weights from historical returns.
+// Weights are fractions summing to 1; returns and volatilities are annualized decimals.
+package portfolio
+
+import "math"
+
+// monthsPerYear annualizes monthly estimates.
+const monthsPerYear = 12
+
+// Estimates holds the annualized return and risk estimates of a set of assets.
+type Estimates struct {
+	// Mean is each asset's expected annual return (arithmetic).
+	Mean []float64
+
+	// Cov is the annualized covariance matrix of the assets' returns.
+	Cov [][]float64
+
+	// maxEigen is the largest eigenvalue of Cov, which bounds the solver's step size.
+	maxEigen float64
+}
+
+// Estimate computes the annualized mean returns and covariance of monthly returns
+// given as returns[month][asset].
+func Estimate(returns [][]float64) Estimates {
+	n := len(returns[0])
+	months := float64(len(returns))
+
+	mean := make([]float64, n)
+	for _, row := range returns {
+		for i, r := range row {
+			mean[i] += r / months
+		}
+	}
+
+	cov := make([][]float64, n)
+	for i := range cov {
+		cov[i] = make([]float64, n)
+	}
+	for _, row := range returns {
+		for i := range n {
+			for j := range n {
+				cov[i][j] += (row[i] - mean[i]) * (row[j] - mean[j])
+			}
+		}
+	}
+
+	denominator := max(months-1, 1)
+	for i := range n {
+		mean[i] *= monthsPerYear
+		for j := range n {
+			cov[i][j] *= monthsPerYear / denominator
+		}
+	}
+
+	return Estimates{Mean: mean, Cov: cov, maxEigen: largestEigenvalue(cov)}
+}
+
+// Return is the expected annual return of weights.
+func (e Estimates) Return(weights []float64) float64 {
+	var r float64
+	for i, w := range weights {
+		r += w * e.Mean[i]
+	}
+	return r
+}
+
+// Volatility is the annualized standard deviation of the returns of weights.
+func (e Estimates) Volatility(weights []float64) float64 {
+	return math.Sqrt(max(e.variance(weights), 0))
+}
+
+// Sharpe is the excess return of weights over riskFree per unit of volatility
+// (0 for a riskless portfolio).
+func (e Estimates) Sharpe(weights []float64, riskFree float64) float64 {
+	volatility := e.Volatility(weights)
+	if volatility < 1e-12 {
+		return 0
+	}
+	return (e.Return(weights) - riskFree) / volatility
+}
+
+// variance is the annualized variance of the returns of weights.
+func (e Estimates) variance(weights []float64) float64 {
+	var v float64
+	for i, row := range e.Cov {
+		for j, c := range row {
+			v += weights[i] * c * weights[j]
+		}
+	}
+	return v
+}
+
+// covTimes returns Cov * weights.
+func (e Estimates) covTimes(weights []float64) []float64 {
+	out := make([]float64, len(weights))
+	for i, row := range e.Cov {
+		for j, c := range row {
+			out[i] += c * weights[j]
+		}
+	}
+	return out
+}
+
+// largestEigenvalue estimates the largest eigenvalue of a symmetric positive
+// semi-definite matrix by power iteration.
+func largestEigenvalue(m [][]float64) float64 {
+	n := len(m)
+	v := make([]float64, n)
+	for i := range v {
+		v[i] = 1 / math.Sqrt(float64(n))
+	}
+
+	var eigen float64
+	for range 100 {
+		next := make([]float64, n)
+		for i, row := range m {
+			for j, c := range row {
+				next[i] += c * v[j]
+			}
+		}
+
+		var norm float64
+		for _, x := range next {
+			norm += x * x
+		}
+		norm = math.Sqrt(norm)
+		if norm == 0 {
+			return 0
+		}
+		for i := range next {
+			next[i] /= norm
+		}
+
+		if math.Abs(norm-eigen) < 1e-12*norm {
+			return norm
+		}
+		eigen, v = norm, next
+	}
+	return eigen
+}
@@ -0,0 +1,267 @@
+package portfolio
+
+import (
+	"math"
+	"slices"
+
+	"github.com/abdonasmane/etfs-simulator/backend/sdk/errors"
+)
+
+const (
+	// maxIterations bounds the projected gradient iterations of a single solve.
+	maxIterations = 5000
+
+	// tolerance is the weight change below which a solve has converged.
+	tolerance = 1e-10
+
+	// minRiskAversion and maxRiskAversion span the risk aversions searched along the
+	// frontier, from (nearly) the maximum-return to (nearly) the minimum-variance portfolio.
+	minRiskAversion = 1e-4
+	maxRiskAversion = 1e5
+
+	// searchSteps is the number of bisection or golden-section steps of a frontier search.
+	searchSteps = 60
+)
+
+// Bounds holds the minimum and maximum weight of each asset. Weights are always long-only.
+type Bounds struct {
+	Min []float64
+	Max []float64
+}
+
+// Validate checks that bounds are long-only and that weights summing to 1 satisfy them.
+func (b Bounds) Validate(n int) error {
+	if len(b.Min) != n || len(b.Max) != n {
+		return errors.New("bounds must be given for every asset")
+	}
+
+	var low, high float64
+	for i := range n {
+		if b.Min[i] < 0 || b.Max[i] > 1 || b.Min[i] > b.Max[i] {
+			return errors.New("weight bounds must satisfy 0 <= min <= max <= 1")
+		}
+		low += b.Min[i]
+		high += b.Max[i]
+	}
+	if low > 1+1e-9 || high < 1-1e-9 {
+		return errors.New("weight bounds cannot be satisfied by weights summing to 100%")
+	}
+
+	return nil
+}
+
+// MinVariance returns the weights with the lowest volatility within bounds.
+func MinVariance(e Estimates, b Bounds) ([]float64, error) {
+	if err := b.Validate(len(e.Mean)); errors.Check(err) {
+		return nil, err
+	}
+	return minVariance(e, b), nil
+}
+
+// MaxSharpe returns the weights with the highest Sharpe ratio within bounds.
+func MaxSharpe(e Estimates, b Bounds, riskFree float64) ([]float64, error) {
+	if err := b.Validate(len(e.Mean)); errors.Check(err) {
+		return nil, err
+	}
+
+	sharpe := func(logGamma float64) float64 {
+		return e.Sharpe(meanVariance(e, b, math.Exp(logGamma), nil), riskFree)
+	}
+
+	// Scan the frontier coarsely, then refine around the best point by golden-section search
+	low, high := math.Log(minRiskAversion), math.Log(maxRiskAversion)
+	const scanPoints = 25
+	step := (high - low) / (scanPoints - 1)
+	best := 0
+	bestSharpe := math.Inf(-1)
+	for k := range scanPoints {
+		if s := sharpe(low + float64(k)*step); s > bestSharpe {
+			best, bestSharpe = k, s
+		}
+	}
+
+	a := low + float64(max(best-1, 0))*step
+	c := low + float64(min(best+1, scanPoints-1))*step
+	ratio := (math.Sqrt(5) - 1) / 2
+	for range searchSteps {
+		x1 := c - ratio*(c-a)
+		x2 := a + ratio*(c-a)
+		if sharpe(x1) >= sharpe(x2) {
+			c = x2
+		} else {
+			a = x1
+		}
+	}
+	weights := meanVariance(e, b, math.Exp((a+c)/2), nil)
+
+	// The frontier's ends are candidates too
+	for _, candidate := range [][]float64{maxReturn(e, b), minVariance(e, b)} {
+		if e.Sharpe(candidate, riskFree) > e.Sharpe(weights, riskFree) {
+			weights = candidate
+		}
+	}
+
+	return weights, nil
+}
+
+// Frontier returns points weights along the efficient frontier within bounds, evenly spaced
+// in expected return from the minimum-variance to the maximum-return portfolio.
+func Frontier(e Estimates, b Bounds, points int) ([][]float64, error) {
+	if err := b.Validate(len(e.Mean)); errors.Check(err) {
+		return nil, err
+	}
+	if points < 2 {
+		return nil, errors.New("frontier must have at least 2 points")
+	}
+
+	lowest := minVariance(e, b)
+	highest := maxReturn(e, b)
+	rMin, rMax := e.Return(lowest), e.Return(highest)
+	if rMax-rMin < 1e-9 {
+		return [][]float64{lowest}, nil
+	}
+
+	frontier := make([][]float64, points)
+	frontier[0], frontier[points-1] = lowest, highest
+
+	// The expected return falls as risk aversion rises, so each target is found by bisection
+	previous := lowest
+	for k := 1; k < points-1; k++ {
+		target := rMin + (rMax-rMin)*float64(k)/float64(points-1)
+		low, high := math.Log(minRiskAversion), math.Log(maxRiskAversion)
+		var weights []float64
+		for range searchSteps {
+			mid := (low + high) / 2
+			weights = meanVariance(e, b, math.Exp(mid), previous)
+			if e.Return(weights) > target {
+				low = mid
+			} else {
+				high = mid
+			}
+		}
+		frontier[k] = weights
+		previous = weights
+	}
+
+	return frontier, nil
+}
+
+// minVariance minimizes the variance of weights within bounds.
+func minVariance(e Estimates, b Bounds) []float64 {
+	zero := make([]float64, len(e.Mean))
+	return solve(e.Cov, zero, e.maxEigen, 1, b, nil)
+}
+
+// meanVariance maximizes expected return minus gamma/2 times the variance within bounds,
+// starting from start when it is not nil.
+func meanVariance(e Estimates, b Bounds, gamma float64, start []float64) []float64 {
+	return solve(e.Cov, e.Mean, e.maxEigen, gamma, b, start)
+}
+
+// maxReturn returns the weights with the highest expected return within bounds: every asset
+// at its minimum, the rest filled in order of expected return.
+func maxReturn(e Estimates, b Bounds) []float64 {
+	n := len(e.Mean)
+	weights := slices.Clone(b.Min)
+	remaining := 1.0
+	for _, w := range weights {
+		remaining -= w
+	}
+
+	order := make([]int, n)
+	for i := range order {
+		order[i] = i
+	}
+	slices.SortStableFunc(order, func(a, c int) int {
+		switch {
+		case e.Mean[a] > e.Mean[c]:
+			return -1
+		case e.Mean[a] < e.Mean[c]:
+			return 1
+		}
+		return 0
+	})
+
+	for _, i := range order {
+		add := min(b.Max[i]-weights[i], remaining)
+		weights[i] += add
+		remaining -= add
+	}
+	return weights
+}
+
+// solve maximizes mean'w - gamma/2 w'Cov w over the weights within bounds by accelerated
+// projected gradient ascent. maxEigen is the largest eigenvalue of cov.
+func solve(cov [][]float64, mean []float64, maxEigen, gamma float64, b Bounds, start []float64) []float64 {
+	lipschitz := gamma * maxEigen
+	if lipschitz < 1e-12 {
+		return maxReturn(Estimates{Mean: mean}, b)
+	}
+	step := 1 / lipschitz
+	e := Estimates{Cov: cov}
+
+	n := len(mean)
+	weights := start
+	if weights == nil {
+		weights = project(make([]float64, n), b)
+	}
+	y := slices.Clone(weights)
+	t := 1.0
+
+	for range maxIterations {
+		gradient := e.covTimes(y)
+		candidate := make([]float64, n)
+		for i := range candidate {
+			candidate[i] = y[i] + step*(mean[i]-gamma*gradient[i])
+		}
+		next := project(candidate, b)
+
+		change := 0.0
+		for i := range next {
+			change = max(change, math.Abs(next[i]-weights[i]))
+		}
+		if change < tolerance {
+			return next
+		}
+
+		tNext := (1 + math.Sqrt(1+4*t*t)) / 2
+		for i := range y {
+			y[i] = next[i] + (t-1)/tNext*(next[i]-weights[i])
+		}
+		weights, t = next, tNext
+	}
+
+	return weights
+}
+
+// project returns the weights within bounds and summing to 1 closest to v: each weight is
+// v[i] - shift clipped to its bounds, with the shift found by bisection.
+func project(v []float64, b Bounds) []float64 {
+	low, high := math.Inf(1), math.Inf(-1)
+	for i, x := range v {
+		low = min(low, x-b.Max[i])
+		high = max(high, x-b.Min[i])
+	}
+
+	weights := make([]float64, len(v))
+	clip := func(shift float64) float64 {
+		var sum float64
+		for i, x := range v {
+			weights[i] = min(max(x-shift, b.Min[i]), b.Max[i])
+			sum += weights[i]
+		}
+		return sum
+	}
+
+	for range 100 {
+		mid := (low + high) / 2
+		if clip(mid) > 1 {
+			low = mid
+		} else {
+			high = mid
+		}
+	}
+	clip((low + high) / 2)
+
+	return weights
+}
@@ -0,0 +1,106 @@
+package portfolio
+
+import (
+	"math"
+	"testing"
+
+	"github.com/abdonasmane/etfs-simulator/backend/sdk/errors"
+)
+
+// twoAssets returns uncorrelated assets with 8% and 4% returns and 20% and 10% volatility.
+func twoAssets() Estimates {
+	cov := [][]float64{{0.04, 0}, {0, 0.01}}
+	return Estimates{Mean: []float64{0.08, 0.04}, Cov: cov, maxEigen: largestEigenvalue(cov)}
+}
+
+// longOnly returns bounds from 0 to 1 for n assets.
+func longOnly(n int) Bounds {
+	b := Bounds{Min: make([]float64, n), Max: make([]float64, n)}
+	for i := range b.Max {
+		b.Max[i] = 1
+	}
+	return b
+}
+
+// TestEstimate tests annualization of monthly mean returns and covariance.
+func TestEstimate(t *testing.T) {
+	returns := [][]float64{{0.01, -0.01}, {0.03, 0.01}}
+	e := Estimate(returns)
+
+	if math.Abs(e.Mean[0]-0.24) > 1e-12 || math.Abs(e.Mean[1]) > 1e-12 {
+		t.Errorf("unexpected means: %v", e.Mean)
+	}
+	// Monthly sample covariance is 0.0002 for every pair
+	for i := range 2 {
+		for j := range 2 {
+			if math.Abs(e.Cov[i][j]-0.0024) > 1e-12 {
+				t.Errorf("unexpected covariance [%d][%d]: %v", i, j, e.Cov[i][j])
+			}
+		}
+	}
+}
+
+// TestMinVariance tests the analytic minimum-variance weights and weight bounds.
+func TestMinVariance(t *testing.T) {
+	e := twoAssets()
+
+	weights, err := MinVariance(e, longOnly(2))
+	if errors.Check(err) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// w1 = var2 / (var1 + var2)
+	if math.Abs(weights[0]-0.2) > 1e-6 || math.Abs(weights[1]-0.8) > 1e-6 {
+		t.Errorf("expected weights [0.2 0.8], got %v", weights)
+	}
+
+	weights, err = MinVariance(e, Bounds{Min: []float64{0.3, 0}, Max: []float64{1, 1}})
+	if errors.Check(err) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if math.Abs(weights[0]-0.3) > 1e-6 {
+		t.Errorf("expected the minimum weight to bind at 0.3, got %v", weights)
+	}
+
+	if _, err := MinVariance(e, Bounds{Min: []float64{0.6, 0.6}, Max: []float64{1, 1}}); !errors.Check(err) {
+		t.Error("expected an error for infeasible bounds")
+	}
+}
+
+// TestMaxSharpe tests the analytic tangency weights of uncorrelated assets.
+func TestMaxSharpe(t *testing.T) {
+	e := twoAssets()
+
+	weights, err := MaxSharpe(e, longOnly(2), 0.02)
+	if errors.Check(err) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// w is proportional to (mean - riskFree) / variance: 1.5 and 2
+	want := 1.5 / 3.5
+	if math.Abs(weights[0]-want) > 1e-4 {
+		t.Errorf("expected first weight %.4f, got %v", want, weights)
+	}
+}
+
+// TestFrontier tests that frontier points are evenly spaced in return with rising volatility.
+func TestFrontier(t *testing.T) {
+	e := twoAssets()
+
+	frontier, err := Frontier(e, longOnly(2), 5)
+	if errors.Check(err) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(frontier) != 5 {
+		t.Fatalf("expected 5 points, got %d", len(frontier))
+	}
+
+	// From 4.8% (minimum variance) to 8% (all in the first asset)
+	for k, weights := range frontier {
+		want := 0.048 + 0.008*float64(k)
+		if r := e.Return(weights); math.Abs(r-want) > 1e-4 {
+			t.Errorf("point %d: expected return %.4f, got %.4f", k, want, r)
+		}
+		if k > 0 && e.Volatility(weights) < e.Volatility(frontier[k-1]) {
+			t.Errorf("point %d: volatility decreased", k)
+		}
+	}
+}